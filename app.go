@@ -201,6 +201,19 @@ func (app *App) buildInstance() (*registry.ServiceInstance, error) {
 
 	if len(endpoints) == 0 {
 		for _, srv := range app.opts.servers {
+			if temp, ok := srv.(transport.MultiEndpointer); ok {
+				es, err := temp.Endpoints()
+				if err != nil {
+					return nil, err
+				}
+
+				for _, e := range es {
+					endpoints = append(endpoints, e.String())
+				}
+
+				continue
+			}
+
 			if temp, ok := srv.(transport.Endpointer); ok {
 				e, err := temp.Endpoint()
 				if err != nil {
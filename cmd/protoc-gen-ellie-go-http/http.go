@@ -6,6 +6,7 @@
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"google.golang.org/genproto/googleapis/api/annotations"
@@ -17,7 +18,6 @@
 
 const (
 	contextPackage       = protogen.GoImportPath("context")
-	ginPackage           = protogen.GoImportPath("github.com/gin-gonic/gin")
 	transportHTTPPackage = protogen.GoImportPath("github.com/dizzrt/ellie/transport/http")
 	ginxPackage          = protogen.GoImportPath("github.com/dizzrt/ellie/transport/http/ginx")
 	otelPackage          = protogen.GoImportPath("go.opentelemetry.io/otel")
@@ -26,14 +26,18 @@
 	attributePackage     = protogen.GoImportPath("go.opentelemetry.io/otel/attribute")
 	propagationPackage   = protogen.GoImportPath("go.opentelemetry.io/otel/propagation")
 	semconvPackage       = protogen.GoImportPath("go.opentelemetry.io/otel/semconv/v1.21.0")
+	grpcPackage          = protogen.GoImportPath("google.golang.org/grpc")
 
 	deprecationComment = "// Deprecated: Do not use."
 )
 
-var methodSets = make(map[string]int)
+var (
+	methodSets     = make(map[string]int)
+	pathVarPattern = regexp.MustCompile(`\{\s*([^{}=\s]+)\s*(=[^{}]*)?\}`)
+)
 
-func generateFile(gen *protogen.Plugin, f *protogen.File, omitempty bool, omitemptyPrefix string) *protogen.GeneratedFile {
-	if len(f.Services) == 0 || omitempty && !hasHTTPRule(f.Services) {
+func generateFile(gen *protogen.Plugin, f *protogen.File, omitempty bool, omitemptyPrefix string, websocket bool) *protogen.GeneratedFile {
+	if len(f.Services) == 0 || omitempty && !hasHTTPRule(f.Services, websocket) {
 		return nil
 	}
 
@@ -52,17 +56,16 @@ func generateFile(gen *protogen.Plugin, f *protogen.File, omitempty bool, omitem
 	g.P("package ", f.GoPackageName)
 	g.P()
 
-	generateContent(gen, f, g, omitempty, omitemptyPrefix)
+	generateContent(gen, f, g, omitempty, omitemptyPrefix, websocket)
 	return g
 }
 
-func generateContent(gen *protogen.Plugin, f *protogen.File, g *protogen.GeneratedFile, omitempty bool, omitemptyPrefix string) {
+func generateContent(gen *protogen.Plugin, f *protogen.File, g *protogen.GeneratedFile, omitempty bool, omitemptyPrefix string, websocket bool) {
 	if len(f.Services) == 0 {
 		return
 	}
 
 	g.P("var _ = new(", contextPackage.Ident("Context"), ")")
-	g.P("var _ = new(", ginPackage.Ident("Engine"), ")")
 	g.P("var _ = new(", ginxPackage.Ident("Ginx"), ")")
 	g.P("var _ = new(", transportHTTPPackage.Ident("Server"), ")")
 	g.P("var _ = ", otelPackage.Ident("Tracer"))
@@ -72,13 +75,14 @@ func generateContent(gen *protogen.Plugin, f *protogen.File, g *protogen.Generat
 	g.P("var _ = new(", propagationPackage.Ident("TextMapPropagator"), ")")
 	g.P("var _ =", semconvPackage.Ident("HTTPRequestMethodKey"))
 
+	routes := make(map[string][]*routeBinding)
 	for _, service := range f.Services {
-		genService(gen, f, g, service, omitempty, omitemptyPrefix)
+		genService(gen, f, g, service, omitempty, omitemptyPrefix, websocket, routes)
 	}
 
 }
 
-func genService(_ *protogen.Plugin, f *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, omitempty bool, omitemptyPrefix string) {
+func genService(_ *protogen.Plugin, f *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, omitempty bool, omitemptyPrefix string, websocket bool, routes map[string][]*routeBinding) {
 	if service.Desc.Options().(*descriptorpb.ServiceOptions).GetDeprecated() {
 		g.P("//")
 		g.P(deprecationComment)
@@ -96,23 +100,39 @@ func genService(_ *protogen.Plugin, f *protogen.File, g *protogen.GeneratedFile,
 	}
 
 	for _, method := range service.Methods {
-		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+		// client and bidi streaming can't be mapped to a single http request,
+		// they are served over websocket when enabled
+		if method.Desc.IsStreamingClient() && !websocket {
 			continue
 		}
 
 		rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
 		if rule != nil && ok {
-			for _, bind := range rule.AdditionalBindings {
-				desc.Methods = append(desc.Methods, buildHTTPRule(g, service, method, bind, omitemptyPrefix))
-			}
-
 			desc.Methods = append(desc.Methods, buildHTTPRule(g, service, method, rule, omitemptyPrefix))
+			for i, bind := range rule.AdditionalBindings {
+				if len(bind.AdditionalBindings) > 0 {
+					_, _ = fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: nested additional_bindings of %s are ignored.\n", method.Desc.FullName())
+				}
+
+				md := buildHTTPRule(g, service, method, bind, omitemptyPrefix)
+				md.Binding = i + 1
+				desc.Methods = append(desc.Methods, md)
+			}
 		} else if !omitempty {
 			path := fmt.Sprintf("%s/%s/%s", omitemptyPrefix, service.Desc.FullName(), method.Desc.Name())
-			desc.Methods = append(desc.Methods, buildMethodDesc(g, method, http.MethodPost, path))
+			httpMethod := http.MethodPost
+			if method.Desc.IsStreamingClient() {
+				httpMethod = http.MethodGet
+			}
+
+			desc.Methods = append(desc.Methods, buildMethodDesc(g, method, httpMethod, path))
 		}
 	}
 
+	for _, m := range desc.Methods {
+		addRoute(routes, &routeBinding{method: m.Method, path: m.Path, rpc: fmt.Sprintf("%s.%s", service.Desc.FullName(), m.OriginalName)})
+	}
+
 	if len(desc.Methods) != 0 {
 		g.P(desc.excute())
 	}
@@ -121,10 +141,10 @@ func genService(_ *protogen.Plugin, f *protogen.File, g *protogen.GeneratedFile,
 
 func buildHTTPRule(g *protogen.GeneratedFile, service *protogen.Service, m *protogen.Method, rule *annotations.HttpRule, omitemptyPrefix string) *methodDesc {
 	var (
-		path   string
-		method string
-		body   string
-		// responseBody string
+		path         string
+		method       string
+		body         string
+		responseBody string
 	)
 
 	switch pattern := rule.Pattern.(type) {
@@ -157,9 +177,18 @@ func buildHTTPRule(g *protogen.GeneratedFile, service *protogen.Service, m *prot
 	}
 
 	body = rule.Body
-	// responseBody = rule.ResponseBody
+	responseBody = rule.ResponseBody
+
+	if m.Desc.IsStreamingClient() && method != http.MethodGet {
+		_, _ = fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: %s %s is a websocket endpoint and is served on GET.\n", method, path)
+		method = http.MethodGet
+	}
 
 	desc := buildMethodDesc(g, m, method, path)
+	if desc.WebSocket {
+		return desc
+	}
+
 	if method == http.MethodGet || method == http.MethodDelete {
 		if body != "" {
 			_, _ = fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: %s %s body should not be declared.\n", method, path)
@@ -180,7 +209,33 @@ func buildHTTPRule(g *protogen.GeneratedFile, service *protogen.Service, m *prot
 		desc.HasBody = false
 	}
 
-	// TODO responseBody
+	desc.BodyField = body
+	if responseBody == "" {
+		return desc
+	}
+
+	if desc.ServerStreaming {
+		_, _ = fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: %s %s response_body is ignored for server streams.\n", method, path)
+		return desc
+	}
+
+	var field *protogen.Field
+	for _, fd := range m.Output.Fields {
+		if string(fd.Desc.Name()) == responseBody {
+			field = fd
+		}
+	}
+
+	if field == nil {
+		fmt.Fprintf(os.Stderr, "\u001B[31mERROR\u001B[m: The response_body field '%s' could not be found in '%s'\n", responseBody, m.Output.Desc.FullName())
+		os.Exit(2)
+	}
+
+	desc.ResponseBody = "." + field.GoName
+	desc.ResponseBodyGetter = ".Get" + field.GoName + "()"
+	if field.Message != nil && !field.Desc.IsList() && !field.Desc.IsMap() {
+		desc.ResponseBodyType = g.QualifiedGoIdent(field.Message.GoIdent)
+	}
 
 	return desc
 }
@@ -190,14 +245,10 @@ func buildMethodDesc(g *protogen.GeneratedFile, m *protogen.Method, method, path
 		methodSets[m.GoName]++
 	}()
 
+	template := path
 	vars := buildPathVars(path)
-	for v, s := range vars {
+	for v := range vars {
 		fields := m.Input.Desc.Fields()
-
-		if s != nil {
-			path = replacePath(v, *s, path)
-		}
-
 		for _, field := range strings.Split(v, ".") {
 			if strings.TrimSpace(field) == "" {
 				continue
@@ -223,8 +274,11 @@ func buildMethodDesc(g *protogen.GeneratedFile, m *protogen.Method, method, path
 		}
 	}
 
-	// Convert path parameters from {param} format to :param format for Gin router
-	path = convertPathParams(path)
+	path, pathVars, err := routePath(template)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\u001B[31mERROR\u001B[m: %v\n", err)
+		os.Exit(2)
+	}
 
 	comment := m.Comments.Leading.String() + m.Comments.Trailing.String()
 	if comment != "" {
@@ -239,16 +293,36 @@ func buildMethodDesc(g *protogen.GeneratedFile, m *protogen.Method, method, path
 		comment += deprecationComment
 	}
 
+	var streamType, genericStreamType string
+	switch {
+	case m.Desc.IsStreamingClient() && m.Desc.IsStreamingServer():
+		streamType = g.QualifiedGoIdent(grpcPackage.Ident("BidiStreamingServer"))
+	case m.Desc.IsStreamingClient():
+		streamType = g.QualifiedGoIdent(grpcPackage.Ident("ClientStreamingServer"))
+	case m.Desc.IsStreamingServer():
+		streamType = g.QualifiedGoIdent(grpcPackage.Ident("ServerStreamingServer"))
+	}
+
+	if m.Desc.IsStreamingClient() {
+		genericStreamType = g.QualifiedGoIdent(grpcPackage.Ident("GenericServerStream"))
+	}
+
 	return &methodDesc{
-		Name:         m.GoName,
-		OriginalName: string(m.Desc.Name()),
-		Num:          methodSets[m.GoName],
-		Request:      g.QualifiedGoIdent(m.Input.GoIdent),
-		Response:     g.QualifiedGoIdent(m.Output.GoIdent),
-		Comment:      comment,
-		Path:         path,
-		Method:       method,
-		HasVars:      len(vars) > 0,
+		Name:              m.GoName,
+		OriginalName:      string(m.Desc.Name()),
+		Num:               methodSets[m.GoName],
+		Request:           g.QualifiedGoIdent(m.Input.GoIdent),
+		Response:          g.QualifiedGoIdent(m.Output.GoIdent),
+		Comment:           comment,
+		Path:              path,
+		Template:          template,
+		PathVars:          pathVars,
+		Method:            method,
+		HasVars:           len(vars) > 0,
+		ServerStreaming:   m.Desc.IsStreamingServer(),
+		WebSocket:         m.Desc.IsStreamingClient(),
+		StreamType:        streamType,
+		GenericStreamType: genericStreamType,
 	}
 }
 
@@ -270,10 +344,10 @@ func buildPathVars(path string) (res map[string]*string) {
 	return
 }
 
-func hasHTTPRule(services []*protogen.Service) bool {
+func hasHTTPRule(services []*protogen.Service, websocket bool) bool {
 	for _, service := range services {
 		for _, method := range service.Methods {
-			if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			if method.Desc.IsStreamingClient() && !websocket {
 				continue
 			}
 
@@ -356,31 +430,83 @@ func isASCIIDigit(c byte) bool {
 	return '0' <= c && c <= '9'
 }
 
-func replacePath(name string, value string, path string) string {
-	pattern := regexp.MustCompile(fmt.Sprintf(`(?i){([\s]*%s\b[\s]*)=?([^{}]*)}`, name))
-	idx := pattern.FindStringIndex(path)
-	if len(idx) > 0 {
-		path = fmt.Sprintf("%s{%s:%s}%s",
-			path[:idx[0]], // The start of the match
-			name,
-			strings.ReplaceAll(value, "*", ".*"),
-			path[idx[1]:],
-		)
-	}
-	return path
-}
+// routePath converts a path template into a router path. Variables matching
+// a single segment become :name, the wildcards of other patterns become the
+// parameters name.0, name.1... which are joined again by ginx.PathVar, e.g.
+// {name=shelves/*} becomes shelves/:name.0 and {name=**} *name.0.
+func routePath(template string) (string, []*pathVar, error) {
+	var vars []*pathVar
+	path := pathVarPattern.ReplaceAllStringFunc(template, func(v string) string {
+		m := pathVarPattern.FindStringSubmatch(v)
+		name, pattern := m[1], strings.TrimSpace(strings.TrimPrefix(m[2], "="))
+		if pattern == "" || pattern == "*" {
+			return ":" + name
+		}
 
-// convertPathParams converts path parameters from {param} format to :param format for Gin router
-func convertPathParams(path string) string {
-	// Use regex to find all {param} patterns and replace with :param
-	pattern := regexp.MustCompile(`\{([^}]+)\}`)
-	return pattern.ReplaceAllStringFunc(path, func(match string) string {
-		// Extract parameter name from {param} or {param:pattern}
-		paramName := strings.TrimPrefix(strings.TrimSuffix(match, "}"), "{")
-		// If there's a pattern (param:pattern), only use the param name
-		if colonIndex := strings.Index(paramName, ":"); colonIndex != -1 {
-			paramName = paramName[:colonIndex]
+		segments := strings.Split(pattern, "/")
+		n := 0
+		for i, seg := range segments {
+			switch seg {
+			case "*":
+				segments[i] = ":" + name + "." + strconv.Itoa(n)
+				n++
+			case "**":
+				segments[i] = "*" + name + "." + strconv.Itoa(n)
+				n++
+			}
 		}
-		return ":" + paramName
+
+		vars = append(vars, &pathVar{Name: name, Pattern: pattern})
+		return strings.Join(segments, "/")
 	})
+
+	if i := strings.Index(path, "/*"); i >= 0 && strings.Contains(path[i+1:], "/") {
+		return "", nil, fmt.Errorf("** must match the last segments of %s", template)
+	}
+
+	if last := path[strings.LastIndex(path, "/")+1:]; strings.HasPrefix(last, ":") && strings.Contains(last[1:], ":") {
+		_, _ = fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: The custom verb of %s can't follow a variable, the verb is matched as part of it.\n", template)
+	}
+
+	return path, vars, nil
+}
+
+// routeBinding is a generated route, routes of a file are checked for
+// conflicts since they are usually registered on the same server.
+type routeBinding struct {
+	method string
+	path   string
+	rpc    string
+}
+
+func addRoute(routes map[string][]*routeBinding, r *routeBinding) {
+	for _, other := range routes[r.method] {
+		if routesConflict(r.path, other.path) {
+			fmt.Fprintf(os.Stderr, "\u001B[31mERROR\u001B[m: %s %s of %s conflicts with %s %s of %s\n", r.method, r.path, r.rpc, other.method, other.path, other.rpc)
+			os.Exit(2)
+		}
+	}
+
+	routes[r.method] = append(routes[r.method], r)
+}
+
+// routesConflict reports whether the router can't register both paths: equal
+// paths, wildcards of different names at the same position and catch-all
+// wildcards next to other segments.
+func routesConflict(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, y := as[i], bs[i]
+		if x == y {
+			continue
+		}
+
+		if strings.HasPrefix(x, "*") || strings.HasPrefix(y, "*") {
+			return true
+		}
+
+		return strings.HasPrefix(x, ":") && strings.HasPrefix(y, ":")
+	}
+
+	return len(as) == len(bs)
 }
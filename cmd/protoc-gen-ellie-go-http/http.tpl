@@ -12,31 +12,39 @@ type {{.ServiceType}}HTTPServer interface {
     {{- if ne .Comment ""}}
     {{.Comment}}
     {{- end}}
+    {{- if .WebSocket}}
+    {{.Name}}({{.StreamType}}[{{.Request}}, {{.Response}}]) error
+    {{- else if .ServerStreaming}}
+    {{.Name}}(*{{.Request}}, {{.StreamType}}[{{.Response}}]) error
+    {{- else}}
     {{.Name}}(context.Context, *{{.Request}}) (*{{.Response}}, error)
+    {{- end}}
 {{- end}}
 }
 
 func Register{{.ServiceType}}HTTPServer(hs *http.Server, srv {{.ServiceType}}HTTPServer) {
-    r := hs.Engine()
-
     {{- range .Methods}}
-    r.{{.Method}}("{{.Path}}", _{{$.FileName}}_{{$svrType}}_{{.Method}}_{{.Name}}_HTTP_Handler(hs, srv))
+    hs.Route("{{.Method}}", "{{.Path}}", Operation{{$svrType}}{{.OriginalName}}, _{{$.FileName}}_{{$svrType}}_{{.Method}}_{{.Name}}{{if .Binding}}_{{.Binding}}{{end}}_HTTP_Handler(hs, srv))
     {{- end}}
 }
 
 {{- range .Methods}}
-func _{{$.FileName}}_{{$svrType}}_{{.Method}}_{{.Name}}_HTTP_Handler(hs *http.Server, srv {{$svrType}}HTTPServer) gin.HandlerFunc {
-    return func(ctx *gin.Context) {
+func _{{$.FileName}}_{{$svrType}}_{{.Method}}_{{.Name}}{{if .Binding}}_{{.Binding}}{{end}}_HTTP_Handler(hs *http.Server, srv {{$svrType}}HTTPServer) http.HandlerFunc {
+    return func(w http.ResponseWriter, greq *http.Request) {
+        {{- if not .WebSocket}}
         var req {{.Request}}
-        if err := ginx.DecodeRequest(ctx, &req); err != nil {
-			ctx.JSON(http.StatusBadRequest, hs.WrapHTTPResponse(nil, err))
-			ctx.Abort()
+        greq, err := ginx.DecodeHTTPRequest(greq, &req, ginx.Body("{{.BodyField}}"){{range .PathVars}}, ginx.PathVar("{{.Name}}", "{{.Pattern}}"){{end}})
+        if err != nil {
+			hs.WriteResponse(w, greq, nil, err)
 			return
 		}
-
-        greq := ctx.Request
+        {{end}}
 		rctx := greq.Context()
+        {{- if .WebSocket}}
+		rctx = log.ExtractFromTextMapCarrier(rctx, http.UpgradeCarrier(greq))
+        {{- else}}
 		rctx = log.ExtractFromTextMapCarrier(rctx, propagation.HeaderCarrier(greq.Header))
+        {{- end}}
 		attributes := []attribute.KeyValue{
 			v1_21_0.HTTPRequestMethodKey.String(greq.Method),
 			v1_21_0.HTTPRouteKey.String(greq.URL.String()),
@@ -54,15 +62,60 @@ func _{{$.FileName}}_{{$svrType}}_{{.Method}}_{{.Name}}_HTTP_Handler(hs *http.Se
 		rctx = log.WithTraceID(rctx, sctx.TraceID().String())
 		rctx = log.WithSpanID(rctx, sctx.SpanID().String())
 
+        greq = greq.WithContext(rctx)
+        {{- if .WebSocket}}
+		http.ServeWebSocket(hs, w, greq, func(stream *{{.GenericStreamType}}[{{.Request}}, {{.Response}}]) error {
+			return srv.{{.Name}}(stream)
+		})
+        {{- else if .ServerStreaming}}
+		http.ServeServerStream(hs, w, greq, func(stream {{.StreamType}}[{{.Response}}]) error {
+			return srv.{{.Name}}(&req, stream)
+		})
+        {{- else}}
         res, err := srv.{{.Name}}(rctx, &req)
-        ctx.Request = ctx.Request.WithContext(rctx)
-		if err != nil {
-			ctx.JSON(http.HTTPStatusCodeFromError(err), hs.WrapHTTPResponse(res, err))
-			ctx.Abort()
-			return
-		}
+		hs.WriteResponse(w, greq, res{{.ResponseBodyGetter}}, err)
+        {{- end}}
+    }
+}
+{{- end}}
+
+{{- if .ClientMethods}}
 
-		hs.EncodeResponse(ctx, res, err)
+type {{.ServiceType}}HTTPClient interface {
+{{- range .ClientMethods}}
+    {{- if ne .Comment ""}}
+    {{.Comment}}
+    {{- end}}
+    {{.Name}}(ctx context.Context, req *{{.Request}}, opts ...http.CallOption) (*{{.Response}}, error)
+{{- end}}
+}
+
+type {{.ServiceType}}HTTPClientImpl struct {
+    cc *http.Client
+}
+
+func New{{.ServiceType}}HTTPClient(client *http.Client) {{.ServiceType}}HTTPClient {
+    return &{{.ServiceType}}HTTPClientImpl{cc: client}
+}
+
+{{- range .ClientMethods}}
+
+func (c *{{$svrType}}HTTPClientImpl) {{.Name}}(ctx context.Context, in *{{.Request}}, opts ...http.CallOption) (*{{.Response}}, error) {
+    path, err := http.EncodeURL("{{.Template}}", in, "{{.BodyField}}")
+    if err != nil {
+        return nil, err
+    }
+
+    var out {{.Response}}
+    {{- if .ResponseBodyType}}
+    out{{.ResponseBody}} = new({{.ResponseBodyType}})
+    {{- end}}
+    err = c.cc.Invoke(ctx, "{{.Method}}", path, {{if .HasBody}}in{{.Body}}{{else}}nil{{end}}, {{if .ResponseBodyType}}out{{.ResponseBody}}{{else if .ResponseBody}}&out{{.ResponseBody}}{{else}}&out{{end}}, opts...)
+    if err != nil {
+        return nil, err
     }
+
+    return &out, nil
 }
 {{- end}}
+{{- end}}
@@ -14,6 +14,7 @@
 	showVersion     = flag.Bool("version", false, "print the version and exit")
 	omitempty       = flag.Bool("omitempty", true, "omit if google.api is empty")
 	omitemptyPrefix = flag.String("omitempty_prefix", "", "omit if google.api is empty")
+	websocket       = flag.Bool("websocket", false, "generate websocket endpoints for client and bidi streaming methods")
 )
 
 func main() {
@@ -32,7 +33,7 @@ func main() {
 				continue
 			}
 
-			generateFile(gen, f, *omitempty, *omitemptyPrefix)
+			generateFile(gen, f, *omitempty, *omitemptyPrefix, *websocket)
 		}
 
 		return nil
@@ -19,26 +19,61 @@ type serviceDesc struct {
 	TracerName  string
 	Methods     []*methodDesc
 	MethodSets  map[string]*methodDesc
+
+	// unary methods in declaration order, additional bindings are only
+	// served
+	ClientMethods []*methodDesc
 }
 
 type methodDesc struct {
 	Name         string
 	OriginalName string
 	Num          int
+	Binding      int
 	Request      string
 	Response     string
 	Comment      string
 	Path         string
+	Template     string
+	PathVars     []*pathVar
 	Method       string
 	HasBody      bool
 	Body         string
+	BodyField    string
 	HasVars      bool
+
+	// the response_body field, its getter and its type when it's a message
+	ResponseBody       string
+	ResponseBodyGetter string
+	ResponseBodyType   string
+
+	// server streaming methods are served as event streams, client and bidi
+	// streaming methods over websocket
+	ServerStreaming   bool
+	WebSocket         bool
+	StreamType        string
+	GenericStreamType string
+}
+
+// pathVar is a path variable matching several segments, see routePath.
+type pathVar struct {
+	Name    string
+	Pattern string
 }
 
 func (sd *serviceDesc) excute() string {
 	sd.MethodSets = make(map[string]*methodDesc)
+	sd.ClientMethods = nil
 	for _, m := range sd.Methods {
+		if _, ok := sd.MethodSets[m.Name]; ok {
+			continue
+		}
+
+		// the main rule comes before the additional bindings
 		sd.MethodSets[m.Name] = m
+		if !m.ServerStreaming && !m.WebSocket {
+			sd.ClientMethods = append(sd.ClientMethods, m)
+		}
 	}
 
 	buf := new(bytes.Buffer)
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const release = "v1.0.0"
+
+var (
+	showVersion     = flag.Bool("version", false, "print the version and exit")
+	format          = flag.String("format", "yaml", "format of the generated documents, yaml or json")
+	naming          = flag.String("naming", "json", "naming of fields, json for the lowerCamelCase json names or proto for the proto names")
+	title           = flag.String("title", "", "title of the documents, the service or package name by default")
+	docVersion      = flag.String("doc_version", "0.0.1", "version of the documents")
+	omitempty       = flag.Bool("omitempty", true, "omit if google.api is empty")
+	omitemptyPrefix = flag.String("omitempty_prefix", "", "path prefix of the POST /{service}/{method} operations documented for methods without google.api.http when omitempty is false")
+)
+
+func main() {
+	flag.Parse()
+	if *showVersion {
+		fmt.Printf("protoc-gen-ellie-openapi %v\n", release)
+		return
+	}
+
+	protogen.Options{
+		ParamFunc: flag.CommandLine.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		if *format != "yaml" && *format != "json" {
+			return fmt.Errorf("unknown format %q, expected yaml or json", *format)
+		}
+
+		for _, f := range gen.Files {
+			if !f.Generate {
+				continue
+			}
+
+			if err := generateFile(gen, f); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
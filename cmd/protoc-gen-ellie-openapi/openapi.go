@@ -0,0 +1,598 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dizzrt/ellie/errors"
+	thttp "github.com/dizzrt/ellie/transport/http"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	openAPIVersion = "3.1.0"
+
+	jsonContentType        = "application/json"
+	problemContentType     = "application/problem+json"
+	eventStreamContentType = "text/event-stream"
+	ndjsonContentType      = "application/x-ndjson"
+
+	// components describing the ellie error responses
+	errorSchemaName   = "ellie.Error"
+	problemSchemaName = "ellie.Problem"
+	errorResponseName = "Error"
+)
+
+// operation methods of OpenAPI path items, custom methods can't be described
+var operationMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPut:     true,
+	http.MethodPost:    true,
+	http.MethodDelete:  true,
+	http.MethodPatch:   true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+type generator struct {
+	file    *protogen.File
+	doc     *document
+	schemas *orderedMap[*schema]
+
+	// error reasons of the package grouped by http status
+	errors map[int][]*errorReason
+}
+
+type errorReason struct {
+	Reason  string
+	Code    int32
+	Comment string
+}
+
+func generateFile(gen *protogen.Plugin, f *protogen.File) error {
+	if len(f.Services) == 0 || *omitempty && !hasHTTPRule(f.Services) {
+		return nil
+	}
+
+	g := &generator{
+		file:    f,
+		schemas: newOrderedMap[*schema](),
+		errors:  collectErrors(gen, f),
+	}
+
+	g.doc = &document{
+		OpenAPI: openAPIVersion,
+		Info: &info{
+			Title:   g.title(),
+			Version: *docVersion,
+		},
+		Paths: newOrderedMap[*pathItem](),
+	}
+
+	for _, service := range f.Services {
+		if err := g.addService(service); err != nil {
+			return err
+		}
+	}
+
+	g.doc.Components = &components{Schemas: g.schemas, Responses: g.errorResponses()}
+	data, err := g.marshal()
+	if err != nil {
+		return err
+	}
+
+	out := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+".openapi."+*format, "")
+	_, err = out.Write(data)
+	return err
+}
+
+func (g *generator) title() string {
+	if *title != "" {
+		return *title
+	}
+
+	if len(g.file.Services) == 1 {
+		return string(g.file.Services[0].Desc.Name())
+	}
+
+	return string(g.file.Desc.Package())
+}
+
+func (g *generator) marshal() ([]byte, error) {
+	if *format == "json" {
+		data, err := json.MarshalIndent(g.doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return append(data, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Code generated by protoc-gen-ellie-openapi. DO NOT EDIT.\n")
+	buf.WriteString(fmt.Sprintf("# versions:\n# - protoc-gen-ellie-openapi %s\n", release))
+	buf.WriteString(fmt.Sprintf("# source: %s\n\n", g.file.Desc.Path()))
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(g.doc); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// region operations
+
+func (g *generator) addService(service *protogen.Service) error {
+	name := string(service.Desc.Name())
+	g.doc.Tags = append(g.doc.Tags, &tag{Name: name, Description: comment(service.Comments.Leading)})
+
+	for _, m := range service.Methods {
+		// client and bidi streams are served over websocket, which OpenAPI
+		// can't describe
+		if m.Desc.IsStreamingClient() {
+			continue
+		}
+
+		var rules []*annotations.HttpRule
+		rule, ok := proto.GetExtension(m.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if rule != nil && ok {
+			rules = append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...)
+		} else if !*omitempty {
+			path := fmt.Sprintf("%s/%s/%s", *omitemptyPrefix, service.Desc.FullName(), m.Desc.Name())
+			rules = append(rules, &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: path}})
+		}
+
+		for i, r := range rules {
+			method, path := ruleMethod(r)
+			if path == "" {
+				path = fmt.Sprintf("%s/%s/%s", *omitemptyPrefix, service.Desc.FullName(), m.Desc.Name())
+			}
+
+			if !operationMethods[method] {
+				_, _ = fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: %s %s of %s can't be described by OpenAPI and is skipped.\n", method, path, m.Desc.FullName())
+				continue
+			}
+
+			op, err := g.buildOperation(m, r, path)
+			if err != nil {
+				return err
+			}
+
+			op.Tags = []string{name}
+			op.OperationID = name + "_" + string(m.Desc.Name())
+			if i > 0 {
+				op.OperationID += "_" + strconv.Itoa(i)
+			}
+
+			openAPIPath, _ := thttp.SimplifyPath(path)
+			item, ok := g.doc.Paths.Get(openAPIPath)
+			if !ok {
+				item = newOrderedMap[*operation]()
+				g.doc.Paths.Set(openAPIPath, item)
+			}
+
+			key := strings.ToLower(method)
+			if other, exists := item.Get(key); exists {
+				return fmt.Errorf("%s %s of %s conflicts with %s", method, path, m.Desc.FullName(), other.OperationID)
+			}
+
+			item.Set(key, op)
+		}
+	}
+
+	return nil
+}
+
+func ruleMethod(rule *annotations.HttpRule) (string, string) {
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToUpper(pattern.Custom.Kind), pattern.Custom.Path
+	}
+
+	return http.MethodPost, ""
+}
+
+func (g *generator) buildOperation(m *protogen.Method, rule *annotations.HttpRule, path string) (*operation, error) {
+	c := m.Comments.Leading
+	if c == "" {
+		c = m.Comments.Trailing
+	}
+
+	summary, description := splitComment(comment(c))
+	op := &operation{
+		Summary:     summary,
+		Description: description,
+		Responses:   newOrderedMap[*response](),
+		Deprecated:  m.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated(),
+	}
+
+	// path parameters
+	bound := make(map[string]bool)
+	_, vars := thttp.SimplifyPath(path)
+	for _, v := range vars {
+		name, pattern := v.Name, v.Pattern
+		fd, err := fieldByPath(m.Input.Desc, name)
+		if err != nil {
+			return nil, fmt.Errorf("path %s of %s: %w", path, m.Desc.FullName(), err)
+		}
+
+		s := g.fieldSchema(fd)
+		if pattern != "" {
+			s.Pattern = patternRegexp(pattern)
+		}
+
+		bound[name] = true
+		op.Parameters = append(op.Parameters, &parameter{
+			Name:        name,
+			In:          "path",
+			Description: fieldComment(fd),
+			Required:    true,
+			Deprecated:  fd.Options().(*descriptorpb.FieldOptions).GetDeprecated(),
+			Schema:      s,
+		})
+	}
+
+	// the body, fields that are neither bound to the path nor to the body
+	// are query parameters
+	switch body := rule.GetBody(); body {
+	case "*":
+		op.RequestBody = &requestBody{Required: true, Content: jsonContent(g.messageRef(m.Input.Desc))}
+	case "":
+		op.Parameters = append(op.Parameters, g.queryParameters(m.Input.Desc, "", "", bound, nil)...)
+	default:
+		fd := m.Input.Desc.Fields().ByName(protoreflect.Name(body))
+		if fd == nil {
+			return nil, fmt.Errorf("body field %s not found in %s", body, m.Input.Desc.FullName())
+		}
+
+		bound[body] = true
+		op.RequestBody = &requestBody{Description: fieldComment(fd), Required: true, Content: jsonContent(g.fieldSchema(fd))}
+		op.Parameters = append(op.Parameters, g.queryParameters(m.Input.Desc, "", "", bound, nil)...)
+	}
+
+	// the response data, server streams send the bare messages as events
+	if m.Desc.IsStreamingServer() {
+		content := newOrderedMap[*mediaType]()
+		content.Set(eventStreamContentType, &mediaType{Schema: g.messageRef(m.Output.Desc)})
+		content.Set(ndjsonContentType, &mediaType{Schema: g.messageRef(m.Output.Desc)})
+		op.Responses.Set("200", &response{Description: "A stream of " + string(m.Output.Desc.Name()) + " events.", Content: content})
+	} else {
+		var data *schema
+		if name := rule.GetResponseBody(); name != "" {
+			fd := m.Output.Desc.Fields().ByName(protoreflect.Name(name))
+			if fd == nil {
+				return nil, fmt.Errorf("response_body field %s not found in %s", name, m.Output.Desc.FullName())
+			}
+
+			data = g.fieldSchema(fd)
+		} else {
+			data = g.messageRef(m.Output.Desc)
+		}
+
+		op.Responses.Set("200", &response{Description: "A successful response.", Content: jsonContent(envelopeSchema(data))})
+	}
+
+	for _, code := range g.errorCodes() {
+		op.Responses.Set(strconv.Itoa(code), &response{Ref: "#/components/responses/" + errorResponseKey(code)})
+	}
+
+	op.Responses.Set("default", &response{Ref: "#/components/responses/" + errorResponseName})
+	return op, nil
+}
+
+// queryParameters returns the query parameters of the fields of md that aren't
+// bound, singular messages are flattened into dotted names.
+func (g *generator) queryParameters(md protoreflect.MessageDescriptor, path, name string, bound map[string]bool, seen []protoreflect.FullName) []*parameter {
+	if slices.Contains(seen, md.FullName()) {
+		return nil
+	}
+
+	seen = append(seen, md.FullName())
+	var params []*parameter
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		fieldPath, key := path+string(fd.Name()), name+fieldName(fd)
+		if bound[fieldPath] || fd.IsMap() {
+			continue
+		}
+
+		if msg := fd.Message(); msg != nil && !isWellKnownScalar(msg) {
+			// repeated messages and dynamic values can't be set by query
+			if !fd.IsList() && !isWellKnownType(msg) {
+				params = append(params, g.queryParameters(msg, fieldPath+".", key+".", bound, seen)...)
+			}
+
+			continue
+		}
+
+		params = append(params, &parameter{
+			Name:        key,
+			In:          "query",
+			Description: fieldComment(fd),
+			Deprecated:  fd.Options().(*descriptorpb.FieldOptions).GetDeprecated(),
+			Schema:      g.fieldSchema(fd),
+		})
+	}
+
+	return params
+}
+
+func fieldByPath(md protoreflect.MessageDescriptor, path string) (protoreflect.FieldDescriptor, error) {
+	var fd protoreflect.FieldDescriptor
+	for _, name := range strings.Split(path, ".") {
+		if md == nil {
+			return nil, fmt.Errorf("field %s is not a message", fd.FullName())
+		}
+
+		if fd = md.Fields().ByName(protoreflect.Name(name)); fd == nil {
+			return nil, fmt.Errorf("field %s not found in %s", path, md.FullName())
+		}
+
+		md = fd.Message()
+	}
+
+	return fd, nil
+}
+
+// patternRegexp converts the pattern of a path variable to a regular
+// expression, e.g. shelves/*/books/* to ^shelves/[^/]+/books/[^/]+$.
+func patternRegexp(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		switch seg {
+		case "*":
+			segments[i] = "[^/]+"
+		case "**":
+			segments[i] = ".+"
+		default:
+			segments[i] = regexp.QuoteMeta(seg)
+		}
+	}
+
+	return "^" + strings.Join(segments, "/") + "$"
+}
+
+func jsonContent(s *schema) *orderedMap[*mediaType] {
+	content := newOrderedMap[*mediaType]()
+	content.Set(jsonContentType, &mediaType{Schema: s})
+	return content
+}
+
+// envelopeSchema describes the envelope json responses are wrapped in.
+func envelopeSchema(data *schema) *schema {
+	properties := newOrderedMap[*schema]()
+	properties.Set("data", data)
+	properties.Set("status", &schema{Type: "integer", Format: "int32", Description: "The status code, 0 on success."})
+	properties.Set("message", &schema{Type: "string"})
+	return &schema{Type: "object", Properties: properties, Required: []string{"data", "status", "message"}}
+}
+
+// endregion
+
+// region errors
+
+// collectErrors returns the reasons of the enums annotated with the ellie
+// error options in the proto package of f.
+func collectErrors(gen *protogen.Plugin, f *protogen.File) map[int][]*errorReason {
+	reasons := make(map[int][]*errorReason)
+	for _, file := range gen.Files {
+		if file.Desc.Package() != f.Desc.Package() {
+			continue
+		}
+
+		for _, enum := range file.Enums {
+			defaultCode, _ := proto.GetExtension(enum.Desc.Options(), errors.E_DefaultCode).(int32)
+			defaultStatus, _ := proto.GetExtension(enum.Desc.Options(), errors.E_DefaultStatus).(errors.ErrorStatus)
+
+			for _, v := range enum.Values {
+				code := defaultCode
+				if c, _ := proto.GetExtension(v.Desc.Options(), errors.E_Code).(int32); c != 0 {
+					code = c
+				}
+
+				if code == defaultCode {
+					continue
+				}
+
+				status := defaultStatus
+				if s, _ := proto.GetExtension(v.Desc.Options(), errors.E_Status).(errors.ErrorStatus); s != errors.ErrorStatus_ERROR_STATUS_UNSPECIFIED {
+					status = s
+				}
+
+				httpStatus := httpStatusFromErrorStatus(status)
+				if httpStatus < http.StatusBadRequest {
+					continue
+				}
+
+				reasons[httpStatus] = append(reasons[httpStatus], &errorReason{
+					Reason:  string(v.Desc.Name()),
+					Code:    code,
+					Comment: comment(v.Comments.Leading + v.Comments.Trailing),
+				})
+			}
+		}
+	}
+
+	return reasons
+}
+
+func (g *generator) errorCodes() []int {
+	codes := make([]int, 0, len(g.errors))
+	for code := range g.errors {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+	return codes
+}
+
+func errorResponseKey(code int) string {
+	return errorResponseName + strconv.Itoa(code)
+}
+
+// errorResponses describes the error responses, the json envelope carries
+// the error code as status next to the reason, like problem documents.
+func (g *generator) errorResponses() *orderedMap[*response] {
+	g.schemas.Set(errorSchemaName, errorSchema(nil))
+	g.schemas.Set(problemSchemaName, problemSchema(0, nil))
+
+	responses := newOrderedMap[*response]()
+	responses.Set(errorResponseName, &response{Description: "An error response.", Content: errorContent(0, nil)})
+	for _, code := range g.errorCodes() {
+		var lines []string
+		for _, r := range g.errors[code] {
+			line := fmt.Sprintf("- `%s` (%d)", r.Reason, r.Code)
+			if r.Comment != "" {
+				line += ": " + strings.ReplaceAll(strings.TrimSpace(r.Comment), "\n", " ")
+			}
+
+			lines = append(lines, line)
+		}
+
+		description := http.StatusText(code)
+		if description == "" {
+			description = "Error " + strconv.Itoa(code)
+		}
+
+		responses.Set(errorResponseKey(code), &response{
+			Description: description + ", the error reasons are:\n\n" + strings.Join(lines, "\n"),
+			Content:     errorContent(code, g.errors[code]),
+		})
+	}
+
+	return responses
+}
+
+func errorContent(code int, reasons []*errorReason) *orderedMap[*mediaType] {
+	content := newOrderedMap[*mediaType]()
+	if len(reasons) == 0 {
+		content.Set(jsonContentType, &mediaType{Schema: &schema{Ref: "#/components/schemas/" + errorSchemaName}})
+		content.Set(problemContentType, &mediaType{Schema: &schema{Ref: "#/components/schemas/" + problemSchemaName}})
+		return content
+	}
+
+	content.Set(jsonContentType, &mediaType{Schema: errorSchema(reasons)})
+	content.Set(problemContentType, &mediaType{Schema: problemSchema(code, reasons)})
+	return content
+}
+
+func errorSchema(reasons []*errorReason) *schema {
+	status := &schema{Type: "integer", Format: "int32", Description: "The error code."}
+	reason := &schema{Type: "string", Description: "The error reason."}
+	for _, r := range reasons {
+		status.Enum = append(status.Enum, r.Code)
+		reason.Enum = append(reason.Enum, r.Reason)
+	}
+
+	properties := newOrderedMap[*schema]()
+	properties.Set("data", &schema{Type: "null"})
+	properties.Set("status", status)
+	properties.Set("message", &schema{Type: "string"})
+	properties.Set("reason", reason)
+	properties.Set("metadata", &schema{Type: "object", AdditionalProperties: &schema{Type: "string"}})
+	return &schema{Type: "object", Properties: properties, Required: []string{"status", "message"}}
+}
+
+// problemSchema describes the RFC 9457 documents rendered for clients that
+// accept application/problem+json.
+func problemSchema(status int, reasons []*errorReason) *schema {
+	code := &schema{Type: "integer", Format: "int32", Description: "The error code."}
+	reason := &schema{Type: "string", Description: "The error reason."}
+	for _, r := range reasons {
+		code.Enum = append(code.Enum, r.Code)
+		reason.Enum = append(reason.Enum, r.Reason)
+	}
+
+	properties := newOrderedMap[*schema]()
+	properties.Set("type", &schema{Type: "string", Format: "uri-reference"})
+	properties.Set("title", &schema{Type: "string"})
+	httpStatus := &schema{Type: "integer", Description: "The http status code."}
+	if status != 0 {
+		httpStatus.Enum = []any{status}
+	}
+
+	properties.Set("status", httpStatus)
+	properties.Set("detail", &schema{Type: "string"})
+	properties.Set("instance", &schema{Type: "string", Format: "uri-reference"})
+	properties.Set("code", code)
+	properties.Set("reason", reason)
+	return &schema{Type: "object", Properties: properties, Required: []string{"type", "title", "status"}, AdditionalProperties: true}
+}
+
+// httpStatusFromErrorStatus maps the status of error reasons like the http
+// server does, there is an offset of 1 between ErrorStatus and grpc codes.
+func httpStatusFromErrorStatus(status errors.ErrorStatus) int {
+	return thttp.HTTPStatusFromGRPCCode(codes.Code(status - 1))
+}
+
+// endregion
+
+func hasHTTPRule(services []*protogen.Service) bool {
+	for _, service := range services {
+		for _, method := range service.Methods {
+			if method.Desc.IsStreamingClient() {
+				continue
+			}
+
+			rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if rule != nil && ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// comment returns the text of a proto comment without the comment markers.
+func comment[T ~string](c T) string {
+	lines := strings.Split(strings.TrimSuffix(string(c), "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimPrefix(line, "//")
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// splitComment splits the first paragraph off a comment as summary.
+func splitComment(c string) (string, string) {
+	summary, description, _ := strings.Cut(c, "\n\n")
+	if strings.Contains(summary, "\n") {
+		return "", c
+	}
+
+	return summary, strings.TrimSpace(description)
+}
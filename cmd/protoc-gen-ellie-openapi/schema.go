@@ -0,0 +1,186 @@
+package main
+
+import (
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// well known types with a special json mapping, see
+// https://protobuf.dev/programming-guides/json/
+var wellKnownSchemas = map[protoreflect.FullName]func() *schema{
+	"google.protobuf.Timestamp": func() *schema { return &schema{Type: "string", Format: "date-time"} },
+	"google.protobuf.Duration": func() *schema {
+		return &schema{Type: "string", Pattern: `^-?[0-9]+(\.[0-9]+)?s$`, Description: "A duration in seconds, e.g. 1.5s."}
+	},
+	"google.protobuf.FieldMask":   func() *schema { return &schema{Type: "string", Description: "Comma separated field paths."} },
+	"google.protobuf.Empty":       func() *schema { return &schema{Type: "object"} },
+	"google.protobuf.Struct":      func() *schema { return &schema{Type: "object", AdditionalProperties: true} },
+	"google.protobuf.Value":       func() *schema { return &schema{} },
+	"google.protobuf.ListValue":   func() *schema { return &schema{Type: "array", Items: &schema{}} },
+	"google.protobuf.DoubleValue": func() *schema { return &schema{Type: []string{"number", "null"}, Format: "double"} },
+	"google.protobuf.FloatValue":  func() *schema { return &schema{Type: []string{"number", "null"}, Format: "float"} },
+	"google.protobuf.Int64Value":  func() *schema { return &schema{Type: []string{"string", "null"}, Format: "int64"} },
+	"google.protobuf.UInt64Value": func() *schema { return &schema{Type: []string{"string", "null"}, Format: "uint64"} },
+	"google.protobuf.Int32Value":  func() *schema { return &schema{Type: []string{"integer", "null"}, Format: "int32"} },
+	"google.protobuf.UInt32Value": func() *schema { return &schema{Type: []string{"integer", "null"}, Format: "uint32"} },
+	"google.protobuf.BoolValue":   func() *schema { return &schema{Type: []string{"boolean", "null"}} },
+	"google.protobuf.StringValue": func() *schema { return &schema{Type: []string{"string", "null"}} },
+	"google.protobuf.BytesValue":  func() *schema { return &schema{Type: []string{"string", "null"}, Format: "byte"} },
+	"google.protobuf.Any": func() *schema {
+		properties := newOrderedMap[*schema]()
+		properties.Set("@type", &schema{Type: "string", Description: "The type URL of the message."})
+		return &schema{Type: "object", Properties: properties, Required: []string{"@type"}, AdditionalProperties: true}
+	},
+}
+
+// isWellKnownType reports whether md is rendered by its json mapping instead of
+// a component schema.
+func isWellKnownType(md protoreflect.MessageDescriptor) bool {
+	_, ok := wellKnownSchemas[md.FullName()]
+	return ok
+}
+
+// isWellKnownScalar reports whether md is rendered as a json scalar, so it can
+// be passed as query parameter.
+func isWellKnownScalar(md protoreflect.MessageDescriptor) bool {
+	if !isWellKnownType(md) {
+		return false
+	}
+
+	switch md.FullName() {
+	case "google.protobuf.Empty", "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.ListValue", "google.protobuf.Any":
+		return false
+	}
+
+	return true
+}
+
+// messageRef returns the schema of md, messages are added to the components
+// and referenced.
+func (g *generator) messageRef(md protoreflect.MessageDescriptor) *schema {
+	if fn, ok := wellKnownSchemas[md.FullName()]; ok {
+		return fn()
+	}
+
+	name := string(md.FullName())
+	if _, ok := g.schemas.Get(name); !ok {
+		s := &schema{Type: "object", Description: comment(md.ParentFile().SourceLocations().ByDescriptor(md).LeadingComments)}
+		g.schemas.Set(name, s)
+
+		properties := newOrderedMap[*schema]()
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			fs := g.fieldSchema(fd)
+			if c := fieldComment(fd); c != "" {
+				fs.Description = c
+			}
+
+			for _, behavior := range fieldBehaviors(fd) {
+				switch behavior {
+				case annotations.FieldBehavior_REQUIRED:
+					s.Required = append(s.Required, fieldName(fd))
+				case annotations.FieldBehavior_OUTPUT_ONLY:
+					fs.ReadOnly = true
+				case annotations.FieldBehavior_INPUT_ONLY:
+					fs.WriteOnly = true
+				}
+			}
+
+			fs.Deprecated = fd.Options().(*descriptorpb.FieldOptions).GetDeprecated()
+			properties.Set(fieldName(fd), fs)
+		}
+
+		if properties.Len() > 0 {
+			s.Properties = properties
+		}
+
+		s.Deprecated = md.Options().(*descriptorpb.MessageOptions).GetDeprecated()
+	}
+
+	return &schema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *generator) enumRef(ed protoreflect.EnumDescriptor) *schema {
+	if ed.FullName() == "google.protobuf.NullValue" {
+		return &schema{Type: "null"}
+	}
+
+	name := string(ed.FullName())
+	if _, ok := g.schemas.Get(name); !ok {
+		s := &schema{Type: "string", Description: comment(ed.ParentFile().SourceLocations().ByDescriptor(ed).LeadingComments)}
+		values := ed.Values()
+		for i := 0; i < values.Len(); i++ {
+			s.Enum = append(s.Enum, string(values.Get(i).Name()))
+		}
+
+		s.Deprecated = ed.Options().(*descriptorpb.EnumOptions).GetDeprecated()
+		g.schemas.Set(name, s)
+	}
+
+	return &schema{Ref: "#/components/schemas/" + name}
+}
+
+// fieldSchema returns the schema of the json value of fd.
+func (g *generator) fieldSchema(fd protoreflect.FieldDescriptor) *schema {
+	switch {
+	case fd.IsMap():
+		return &schema{Type: "object", AdditionalProperties: g.singularSchema(fd.MapValue())}
+	case fd.IsList():
+		return &schema{Type: "array", Items: g.singularSchema(fd)}
+	}
+
+	return g.singularSchema(fd)
+}
+
+func (g *generator) singularSchema(fd protoreflect.FieldDescriptor) *schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &schema{Type: "integer", Format: "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// 64-bit integers are json strings
+		return &schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		return g.enumRef(fd.Enum())
+	}
+
+	return g.messageRef(fd.Message())
+}
+
+func fieldName(fd protoreflect.FieldDescriptor) string {
+	if *naming == "proto" {
+		return string(fd.Name())
+	}
+
+	return fd.JSONName()
+}
+
+func fieldComment(fd protoreflect.FieldDescriptor) string {
+	loc := fd.ParentFile().SourceLocations().ByDescriptor(fd)
+	if loc.LeadingComments != "" {
+		return comment(loc.LeadingComments)
+	}
+
+	return comment(loc.TrailingComments)
+}
+
+func fieldBehaviors(fd protoreflect.FieldDescriptor) []annotations.FieldBehavior {
+	behaviors, _ := proto.GetExtension(fd.Options(), annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+	return behaviors
+}
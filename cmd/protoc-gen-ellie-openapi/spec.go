@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the subset of OpenAPI 3.1 emitted by the generator
+
+type document struct {
+	OpenAPI    string                 `json:"openapi" yaml:"openapi"`
+	Info       *info                  `json:"info" yaml:"info"`
+	Tags       []*tag                 `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Paths      *orderedMap[*pathItem] `json:"paths" yaml:"paths"`
+	Components *components            `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+type info struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+type tag struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// pathItem maps lower case methods to operations.
+type pathItem = orderedMap[*operation]
+
+type operation struct {
+	Tags        []string               `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string                 `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID string                 `json:"operationId" yaml:"operationId"`
+	Parameters  []*parameter           `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *requestBody           `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   *orderedMap[*response] `json:"responses" yaml:"responses"`
+	Deprecated  bool                   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name" yaml:"name"`
+	In          string  `json:"in" yaml:"in"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Deprecated  bool    `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Schema      *schema `json:"schema" yaml:"schema"`
+}
+
+type requestBody struct {
+	Description string                  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool                    `json:"required,omitempty" yaml:"required,omitempty"`
+	Content     *orderedMap[*mediaType] `json:"content" yaml:"content"`
+}
+
+type response struct {
+	Ref         string                  `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Description string                  `json:"description,omitempty" yaml:"description,omitempty"`
+	Content     *orderedMap[*mediaType] `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema" yaml:"schema"`
+}
+
+type components struct {
+	Schemas   *orderedMap[*schema]   `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	Responses *orderedMap[*response] `json:"responses,omitempty" yaml:"responses,omitempty"`
+}
+
+type schema struct {
+	Ref                  string               `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 any                  `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string               `json:"format,omitempty" yaml:"format,omitempty"`
+	Title                string               `json:"title,omitempty" yaml:"title,omitempty"`
+	Description          string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Pattern              string               `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Enum                 []any                `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Items                *schema              `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           *orderedMap[*schema] `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties any                  `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string             `json:"required,omitempty" yaml:"required,omitempty"`
+	ReadOnly             bool                 `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	WriteOnly            bool                 `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
+	Deprecated           bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// orderedMap is a map marshaled in insertion order, so paths and properties
+// follow the proto declarations.
+type orderedMap[V any] struct {
+	keys   []string
+	values map[string]V
+}
+
+func newOrderedMap[V any]() *orderedMap[V] {
+	return &orderedMap[V]{values: make(map[string]V)}
+}
+
+func (m *orderedMap[V]) Set(key string, value V) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+
+	m.values[key] = value
+}
+
+func (m *orderedMap[V]) Get(key string) (V, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+func (m *orderedMap[V]) Len() int {
+	return len(m.keys)
+}
+
+func (m *orderedMap[V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (m *orderedMap[V]) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range m.keys {
+		value := new(yaml.Node)
+		if err := value.Encode(m.values[k]); err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, value)
+	}
+
+	return node, nil
+}
@@ -1,10 +1,16 @@
 package encoding
 
 import (
+	"mime"
 	"strings"
+	"sync"
 )
 
-var codecs = make(map[string]Codec)
+var (
+	mu           sync.RWMutex
+	codecs       = make(map[string]Codec)
+	contentTypes = make(map[string]string)
+)
 
 type Codec interface {
 	Marshal(v any) ([]byte, error)
@@ -12,6 +18,12 @@ type Codec interface {
 	Name() string
 }
 
+// ContentTyper is implemented by codecs that declare the media types they
+// handle, RegisterCodec indexes them for GetCodecByContentType.
+type ContentTyper interface {
+	ContentTypes() []string
+}
+
 func RegisterCodec(codec Codec) {
 	if codec == nil {
 		panic("can't register a nil codec")
@@ -21,10 +33,65 @@ func RegisterCodec(codec Codec) {
 		panic("can't register a codec with empty name")
 	}
 
+	mu.Lock()
+	defer mu.Unlock()
+
 	codecType := strings.ToLower(codec.Name())
 	codecs[codecType] = codec
+	if ct, ok := codec.(ContentTyper); ok {
+		for _, contentType := range ct.ContentTypes() {
+			contentTypes[strings.ToLower(contentType)] = codecType
+		}
+	}
+}
+
+// RegisterContentType maps an additional media type to a codec name.
+func RegisterContentType(contentType, codecType string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	contentTypes[strings.ToLower(contentType)] = strings.ToLower(codecType)
 }
 
 func GetCodec(codecType string) Codec {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	return codecs[codecType]
 }
+
+// GetCodecByContentType returns the codec for a media type, parameters are
+// ignored and structured syntax suffixes such as +json fall back to the codec
+// named by the suffix.
+func GetCodecByContentType(contentType string) Codec {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if name, ok := contentTypes[mt]; ok {
+		return codecs[name]
+	}
+
+	if idx := strings.LastIndex(mt, "+"); idx >= 0 {
+		return codecs[mt[idx+1:]]
+	}
+
+	return nil
+}
+
+// ContentTypes returns the registered media types.
+func ContentTypes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	res := make([]string, 0, len(contentTypes))
+	for contentType := range contentTypes {
+		res = append(res, contentType)
+	}
+
+	return res
+}
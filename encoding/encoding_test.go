@@ -0,0 +1,77 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/dizzrt/ellie/encoding"
+	_ "github.com/dizzrt/ellie/encoding/form"
+	"github.com/dizzrt/ellie/encoding/json"
+	_ "github.com/dizzrt/ellie/encoding/proto"
+	_ "github.com/dizzrt/ellie/encoding/toml"
+	_ "github.com/dizzrt/ellie/encoding/xml"
+	_ "github.com/dizzrt/ellie/encoding/yaml"
+	"github.com/dizzrt/ellie/internal/mock/binding"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGetCodecByContentType(t *testing.T) {
+	tests := map[string]string{
+		"application/json; charset=utf-8":   "json",
+		"application/problem+json":          "json",
+		"application/x-protobuf":            "proto",
+		"text/xml":                          "xml",
+		"application/x-yaml":                "yaml",
+		"application/toml":                  "toml",
+		"application/x-www-form-urlencoded": "x-www-form-urlencoded",
+	}
+
+	for contentType, name := range tests {
+		codec := encoding.GetCodecByContentType(contentType)
+		if assert.NotNil(t, codec, contentType) {
+			assert.Equal(t, name, codec.Name())
+		}
+	}
+
+	assert.Nil(t, encoding.GetCodecByContentType("text/csv"))
+
+	encoding.RegisterContentType("application/vnd.ellie.v1", "json")
+	assert.Equal(t, "json", encoding.GetCodecByContentType("application/vnd.ellie.v1").Name())
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	want := &binding.BindRequest{
+		Name:   "ellie",
+		Id:     42,
+		Status: binding.Status_STATUS_ACTIVE,
+		Tags:   []string{"a", "b"},
+		User:   &binding.User{Id: 1, DisplayName: "mike"},
+	}
+
+	for _, name := range []string{"json", "proto", "yaml", "toml", "x-www-form-urlencoded"} {
+		t.Run(name, func(t *testing.T) {
+			codec := encoding.GetCodec(name)
+			data, err := codec.Marshal(want)
+			assert.NoError(t, err)
+
+			got := &binding.BindRequest{}
+			assert.NoError(t, codec.Unmarshal(data, got))
+			assert.True(t, proto.Equal(want, got), "want %v\ngot  %v", want, got)
+		})
+	}
+}
+
+func TestJSONOptions(t *testing.T) {
+	codec := json.NewCodec(json.UseProtoNames(true), json.EmitUnpopulated(true), json.UseEnumNumbers(true))
+	data, err := codec.Marshal(&binding.User{DisplayName: "mike"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"0","display_name":"mike"}`, string(data))
+
+	data, err = codec.Marshal(&binding.BindRequest{Status: binding.Status_STATUS_DISABLED})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"status":2`)
+
+	data, err = encoding.GetCodec(json.Name).Marshal(&binding.User{DisplayName: "mike"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"displayName":"mike"}`, string(data))
+}
@@ -9,7 +9,10 @@
 	"google.golang.org/protobuf/proto"
 )
 
-var _ encoding.Codec = (*codec)(nil)
+var (
+	_ encoding.Codec        = (*codec)(nil)
+	_ encoding.ContentTyper = (*codec)(nil)
+)
 
 type codec struct {
 	encoder *form.Encoder
@@ -38,6 +41,12 @@ func (codec) Name() string {
 	return Name
 }
 
+func (codec) ContentTypes() []string {
+	return []string{
+		"application/x-www-form-urlencoded",
+	}
+}
+
 func (c codec) Marshal(v any) ([]byte, error) {
 	var err error
 	var vals url.Values
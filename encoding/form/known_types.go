@@ -32,7 +32,18 @@
 	structFieldsFieldNumber protoreflect.FieldNumber = 1
 	structMessageFullname   protoreflect.FullName    = "google.protobuf.Struct"
 
-	fieldMaskFullName protoreflect.FullName = "google.protobuf.FieldMask"
+	// google.protobuf.Value and google.protobuf.ListValue.
+	valueMessageFullname     protoreflect.FullName = "google.protobuf.Value"
+	listValueMessageFullname protoreflect.FullName = "google.protobuf.ListValue"
+	nullValueFullName        protoreflect.FullName = "google.protobuf.NullValue"
+
+	fieldMaskFullName         protoreflect.FullName    = "google.protobuf.FieldMask"
+	fieldMaskPathsFieldNumber protoreflect.FieldNumber = 1
+
+	emptyMessageFullname protoreflect.FullName = "google.protobuf.Empty"
+
+	// google.protobuf.*Value wrappers
+	wrapperValueFieldNumber protoreflect.FieldNumber = 1
 )
 
 func marshalTimestamp(m protoreflect.Message) (string, error) {
@@ -54,7 +65,7 @@ func marshalTimestamp(m protoreflect.Message) (string, error) {
 
 	// Uses RFC 3339, where generated output will be Z-normalized and uses 0, 3,
 	// 6 or 9 fractional digits.
-	t := time.Unix(secs, nanos).Local()
+	t := time.Unix(secs, nanos).UTC()
 	x := t.Format("2006-01-02T15:04:05.000000000")
 	x = strings.TrimSuffix(x, "000")
 	x = strings.TrimSuffix(x, "000")
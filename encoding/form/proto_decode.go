@@ -1,12 +1,309 @@
 package form
 
 import (
+	"encoding/base64"
+	"fmt"
 	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+var mapKeyPattern = regexp.MustCompile(`^(.*)\[(.*)\]$`)
+
+// FieldError reports a value that could not be bound to a message field.
+type FieldError struct {
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid value %q for field %q: %v", e.Value, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeValues binds url values into msg. Keys are dotted field paths using
+// either the proto or the json field name, e.g. user.id or user.displayName,
+// map entries are addressed as labels[key]. Unknown fields are ignored.
 func DecodeValues(msg proto.Message, values url.Values) error {
-	// TODO DecodeValues
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+	for _, key := range keys {
+		if err := populateFieldValues(msg.ProtoReflect(), key, values[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func populateFieldValues(v protoreflect.Message, key string, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	path, mapKey, isMapEntry := key, "", false
+	if m := mapKeyPattern.FindStringSubmatch(key); m != nil {
+		path, mapKey, isMapEntry = m[1], m[2], true
+	}
+
+	names := strings.Split(path, ".")
+	var fd protoreflect.FieldDescriptor
+	for i, name := range names {
+		fd = fieldByName(v.Descriptor().Fields(), name)
+		if fd == nil {
+			return nil
+		}
+
+		if i == len(names)-1 {
+			break
+		}
+
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return &FieldError{Field: key, Value: values[0], Err: fmt.Errorf("%s is not a message field", fd.FullName())}
+		}
+
+		if err := checkOneof(v, fd); err != nil {
+			return &FieldError{Field: key, Value: values[0], Err: err}
+		}
+
+		v = v.Mutable(fd).Message()
+	}
+
+	if err := checkOneof(v, fd); err != nil {
+		return &FieldError{Field: key, Value: values[0], Err: err}
+	}
+
+	var err error
+	switch {
+	case fd.IsList():
+		err = populateRepeatedField(v, fd, values)
+	case fd.IsMap():
+		if !isMapEntry {
+			return &FieldError{Field: key, Value: values[0], Err: fmt.Errorf("map entries must be addressed as %s[key]", path)}
+		}
+
+		err = populateMapField(v, fd, mapKey, values[len(values)-1])
+	default:
+		var val protoreflect.Value
+		if val, err = parseField(v.NewField(fd), fd, values[len(values)-1]); err == nil {
+			v.Set(fd, val)
+		}
+	}
+
+	if err != nil {
+		if fe, ok := err.(*FieldError); ok {
+			fe.Field = key
+			return fe
+		}
+
+		return &FieldError{Field: key, Value: values[len(values)-1], Err: err}
+	}
+
 	return nil
 }
+
+func fieldByName(fds protoreflect.FieldDescriptors, name string) protoreflect.FieldDescriptor {
+	if fd := fds.ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+
+	return fds.ByJSONName(name)
+}
+
+func checkOneof(v protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	od := fd.ContainingOneof()
+	if od == nil || od.IsSynthetic() {
+		return nil
+	}
+
+	if set := v.WhichOneof(od); set != nil && set.Number() != fd.Number() {
+		return fmt.Errorf("oneof %s is already set by field %s", od.Name(), set.Name())
+	}
+
+	return nil
+}
+
+func populateRepeatedField(v protoreflect.Message, fd protoreflect.FieldDescriptor, values []string) error {
+	list := v.Mutable(fd).List()
+	for _, value := range values {
+		val, err := parseField(list.NewElement(), fd, value)
+		if err != nil {
+			return &FieldError{Value: value, Err: err}
+		}
+
+		list.Append(val)
+	}
+
+	return nil
+}
+
+func populateMapField(v protoreflect.Message, fd protoreflect.FieldDescriptor, key, value string) error {
+	mp := v.Mutable(fd).Map()
+	k, err := parseField(protoreflect.Value{}, fd.MapKey(), key)
+	if err != nil {
+		return &FieldError{Value: key, Err: err}
+	}
+
+	val, err := parseField(mp.NewValue(), fd.MapValue(), value)
+	if err != nil {
+		return err
+	}
+
+	mp.Set(k.MapKey(), val)
+	return nil
+}
+
+// parseField parses value for the kind of fd, zero is a new value of the
+// field type and is only used for message fields.
+func parseField(zero protoreflect.Value, fd protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(value)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.EnumKind:
+		return parseEnum(fd.Enum(), value)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(value, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(value, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(value, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(value, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(value, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BytesKind:
+		v, err := decodeBytes(value)
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		msg := zero.Message()
+		return zero, parseMessage(msg, value)
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+	}
+}
+
+func parseEnum(ed protoreflect.EnumDescriptor, value string) (protoreflect.Value, error) {
+	if ed.FullName() == nullValueFullName {
+		return protoreflect.ValueOfEnum(0), nil
+	}
+
+	if ev := ed.Values().ByName(protoreflect.Name(value)); ev != nil {
+		return protoreflect.ValueOfEnum(ev.Number()), nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return protoreflect.Value{}, fmt.Errorf("unknown value for enum %s", ed.FullName())
+	}
+
+	return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+}
+
+func decodeBytes(value string) ([]byte, error) {
+	enc := base64.StdEncoding
+	if strings.ContainsAny(value, "-_") {
+		enc = base64.URLEncoding
+	}
+
+	if len(value)%4 != 0 {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+
+	return enc.DecodeString(value)
+}
+
+// parseMessage parses the string form of well known types, other messages
+// are expected to be protojson encoded.
+func parseMessage(msg protoreflect.Message, value string) error {
+	md := msg.Descriptor()
+	fds := md.Fields()
+	switch md.FullName() {
+	case timestampMessageFullname:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return err
+		}
+
+		msg.Set(fds.ByNumber(timestampSecondsFieldNumber), protoreflect.ValueOfInt64(t.Unix()))
+		msg.Set(fds.ByNumber(timestampNanosFieldNumber), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+	case durationMessageFullname:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		msg.Set(fds.ByNumber(durationSecondsFieldNumber), protoreflect.ValueOfInt64(int64(d/time.Second)))
+		msg.Set(fds.ByNumber(durationNanosFieldNumber), protoreflect.ValueOfInt32(int32(d%time.Second)))
+	case fieldMaskFullName:
+		list := msg.Mutable(fds.ByNumber(fieldMaskPathsFieldNumber)).List()
+		for path := range strings.SplitSeq(value, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				list.Append(protoreflect.ValueOfString(snakeCase(path)))
+			}
+		}
+	case emptyMessageFullname:
+	case structMessageFullname, valueMessageFullname, listValueMessageFullname:
+		return protojson.Unmarshal([]byte(value), msg.Interface())
+	default:
+		if isWrapper(md.FullName()) {
+			fd := fds.ByNumber(wrapperValueFieldNumber)
+			val, err := parseField(protoreflect.Value{}, fd, value)
+			if err != nil {
+				return err
+			}
+
+			msg.Set(fd, val)
+			return nil
+		}
+
+		return protojson.Unmarshal([]byte(value), msg.Interface())
+	}
+
+	return nil
+}
+
+func isWrapper(name protoreflect.FullName) bool {
+	return name.Parent() == "google.protobuf" && strings.HasSuffix(string(name.Name()), "Value") &&
+		name != valueMessageFullname && name != listValueMessageFullname
+}
+
+// snakeCase converts lowerCamelCase field mask paths to proto field names.
+func snakeCase(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+			r += 'a' - 'A'
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
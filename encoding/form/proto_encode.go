@@ -1,8 +1,170 @@
 package form
 
-import "net/url"
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// EncodeValues encodes msg into url values, it is the inverse of DecodeValues.
+// Proto messages use json field names and dotted paths for nested messages,
+// other values are encoded by their json tags.
 func EncodeValues(msg any) (url.Values, error) {
-	// TODO EncodeValues
-	return nil, nil
+	if msg == nil {
+		return url.Values{}, nil
+	}
+
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return encoder.Encode(msg)
+	}
+
+	values := make(url.Values)
+	if err := encodeByField("", m.ProtoReflect(), values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func encodeByField(prefix string, m protoreflect.Message, values url.Values) (err error) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		key := prefix + fd.JSONName()
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				var s string
+				if s, err = encodeField(fd, list.Get(i)); err != nil {
+					return false
+				}
+
+				values.Add(key, s)
+			}
+		case fd.IsMap():
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				var s string
+				if s, err = encodeField(fd.MapValue(), mv); err != nil {
+					return false
+				}
+
+				values.Set(key+"["+k.String()+"]", s)
+				return true
+			})
+		case fd.Message() != nil && !isKnownType(fd.Message().FullName()):
+			err = encodeByField(key+".", v.Message(), values)
+		default:
+			var s string
+			if s, err = encodeField(fd, v); err == nil {
+				values.Set(key, s)
+			}
+		}
+
+		return err == nil
+	})
+
+	return err
+}
+
+func encodeField(fd protoreflect.FieldDescriptor, v protoreflect.Value) (string, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(v.Bool()), nil
+	case protoreflect.EnumKind:
+		if fd.Enum().FullName() == nullValueFullName {
+			return "null", nil
+		}
+
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+
+		return strconv.FormatInt(int64(v.Enum()), 10), nil
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case protoreflect.BytesKind:
+		return base64.URLEncoding.EncodeToString(v.Bytes()), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return encodeMessage(v.Message())
+	default:
+		return v.String(), nil
+	}
+}
+
+// encodeMessage encodes well known types in their string form, other
+// messages are protojson encoded.
+func encodeMessage(m protoreflect.Message) (string, error) {
+	md := m.Descriptor()
+	switch md.FullName() {
+	case timestampMessageFullname:
+		return marshalTimestamp(m)
+	case durationMessageFullname:
+		return marshalDuration(m)
+	case bytesMessageFullname:
+		return marshalBytes(m)
+	case fieldMaskFullName:
+		list := m.Get(md.Fields().ByNumber(fieldMaskPathsFieldNumber)).List()
+		paths := make([]string, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			paths = append(paths, lowerCamelCase(list.Get(i).String()))
+		}
+
+		return strings.Join(paths, ","), nil
+	case emptyMessageFullname:
+		return "", nil
+	case structMessageFullname, valueMessageFullname, listValueMessageFullname:
+		data, err := protojson.Marshal(m.Interface())
+		return string(data), err
+	default:
+		if isWrapper(md.FullName()) {
+			fd := md.Fields().ByNumber(wrapperValueFieldNumber)
+			return encodeField(fd, m.Get(fd))
+		}
+
+		data, err := protojson.Marshal(m.Interface())
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", md.FullName(), err)
+		}
+
+		return string(data), nil
+	}
+}
+
+func isKnownType(name protoreflect.FullName) bool {
+	switch name {
+	case timestampMessageFullname, durationMessageFullname, fieldMaskFullName, emptyMessageFullname,
+		structMessageFullname, valueMessageFullname, listValueMessageFullname:
+		return true
+	}
+
+	return isWrapper(name)
+}
+
+// lowerCamelCase converts proto field mask paths to their json form.
+func lowerCamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if r == '_' {
+			upper = true
+			continue
+		}
+
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+
+		upper = false
+		b.WriteRune(r)
+	}
+
+	return b.String()
 }
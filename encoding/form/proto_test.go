@@ -0,0 +1,83 @@
+package form
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dizzrt/ellie/internal/mock/binding"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newBindRequest() *binding.BindRequest {
+	extra, _ := structpb.NewStruct(map[string]any{"k": "v"})
+	return &binding.BindRequest{
+		Name:       "ellie",
+		Id:         9007199254740993,
+		Size:       18446744073709551615,
+		Enabled:    true,
+		Score:      1.5,
+		Data:       []byte("hello?"),
+		Status:     binding.Status_STATUS_ACTIVE,
+		Tags:       []string{"a", "b"},
+		Statuses:   []binding.Status{binding.Status_STATUS_ACTIVE, binding.Status_STATUS_DISABLED},
+		Labels:     map[string]string{"env": "dev", "zone": "a"},
+		User:       &binding.User{Id: 42, DisplayName: "mike"},
+		Filter:     &binding.BindRequest_Category{Category: 3},
+		CreateTime: timestamppb.New(time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC)),
+		Timeout:    durationpb.New(90 * time.Second),
+		Nickname:   wrapperspb.String("ellie"),
+		Limit:      wrapperspb.Int64(10),
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "user.display_name"}},
+		Extra:      extra,
+		Cursor:     proto.String(""),
+	}
+}
+
+func TestEncodeValues(t *testing.T) {
+	values, err := EncodeValues(newBindRequest())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "9007199254740993", values.Get("id"))
+	assert.Equal(t, "STATUS_ACTIVE", values.Get("status"))
+	assert.Equal(t, []string{"STATUS_ACTIVE", "STATUS_DISABLED"}, values["statuses"])
+	assert.Equal(t, "dev", values.Get("labels[env]"))
+	assert.Equal(t, "mike", values.Get("user.displayName"))
+	assert.Equal(t, "3", values.Get("category"))
+	assert.Equal(t, "2024-01-02T03:04:05.500Z", values.Get("createTime"))
+	assert.Equal(t, "1m30s", values.Get("timeout"))
+	assert.Equal(t, "10", values.Get("limit"))
+	assert.Equal(t, "name,user.displayName", values.Get("updateMask"))
+	assert.JSONEq(t, `{"k":"v"}`, values.Get("extra"))
+	assert.Equal(t, []string{""}, values["cursor"])
+}
+
+func TestProtoRoundTrip(t *testing.T) {
+	want := newBindRequest()
+	c := codec{encoder: encoder, decoder: decoder}
+
+	data, err := c.Marshal(want)
+	assert.NoError(t, err)
+
+	got := &binding.BindRequest{}
+	assert.NoError(t, c.Unmarshal(data, got))
+	assert.True(t, proto.Equal(want, got), "want %v\ngot  %v", want, got)
+}
+
+func TestDecodeValuesErrors(t *testing.T) {
+	err := DecodeValues(&binding.BindRequest{}, url.Values{"user.id": {"x"}})
+	fe, ok := err.(*FieldError)
+	if assert.True(t, ok, "expected *FieldError, got %T", err) {
+		assert.Equal(t, "user.id", fe.Field)
+		assert.Equal(t, "x", fe.Value)
+	}
+
+	err = DecodeValues(&binding.BindRequest{}, url.Values{"name.first": {"x"}})
+	assert.Error(t, err)
+}
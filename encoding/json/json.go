@@ -0,0 +1,147 @@
+package json
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/bytedance/sonic"
+	"github.com/dizzrt/ellie/encoding"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	_ encoding.Codec        = (*codec)(nil)
+	_ encoding.ContentTyper = (*codec)(nil)
+)
+
+const Name = "json"
+
+var (
+	// MarshalOptions is used by the registered codec for proto messages.
+	MarshalOptions = protojson.MarshalOptions{}
+
+	// UnmarshalOptions is used by the registered codec for proto messages.
+	UnmarshalOptions = protojson.UnmarshalOptions{
+		DiscardUnknown: true,
+	}
+)
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+type Option func(*codec)
+
+// UseProtoNames uses the proto field names instead of the lowerCamelCase json names.
+func UseProtoNames(enable bool) Option {
+	return func(c *codec) {
+		c.marshalOptions.UseProtoNames = enable
+	}
+}
+
+// EmitUnpopulated emits fields that are not set.
+func EmitUnpopulated(enable bool) Option {
+	return func(c *codec) {
+		c.marshalOptions.EmitUnpopulated = enable
+	}
+}
+
+// UseEnumNumbers emits enum values as numbers instead of names.
+func UseEnumNumbers(enable bool) Option {
+	return func(c *codec) {
+		c.marshalOptions.UseEnumNumbers = enable
+	}
+}
+
+// DiscardUnknown ignores unknown fields when decoding proto messages.
+func DiscardUnknown(enable bool) Option {
+	return func(c *codec) {
+		c.unmarshalOptions.DiscardUnknown = enable
+	}
+}
+
+// NewCodec returns a json codec with its own protojson options, register it
+// with encoding.RegisterCodec to replace the default one.
+func NewCodec(opts ...Option) encoding.Codec {
+	c := &codec{
+		marshalOptions:   &protojson.MarshalOptions{},
+		unmarshalOptions: &protojson.UnmarshalOptions{DiscardUnknown: true},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return *c
+}
+
+type codec struct {
+	// nil options fall back to MarshalOptions and UnmarshalOptions
+	marshalOptions   *protojson.MarshalOptions
+	unmarshalOptions *protojson.UnmarshalOptions
+}
+
+func (codec) Name() string {
+	return Name
+}
+
+func (codec) ContentTypes() []string {
+	return []string{
+		"application/json",
+	}
+}
+
+func (c codec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case json.Marshaler:
+		return m.MarshalJSON()
+	case proto.Message:
+		if c.marshalOptions != nil {
+			return c.marshalOptions.Marshal(m)
+		}
+
+		return MarshalOptions.Marshal(m)
+	default:
+		return sonic.Marshal(m)
+	}
+}
+
+func (c codec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case json.Unmarshaler:
+		return m.UnmarshalJSON(data)
+	case proto.Message:
+		return c.unmarshalProto(data, m)
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv := rv; rv.Kind() == reflect.Ptr; {
+		if rv.IsNil() {
+			if !rv.CanSet() {
+				return &json.InvalidUnmarshalError{Type: rv.Type()}
+			}
+
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		rv = rv.Elem()
+		if m, ok := rv.Addr().Interface().(proto.Message); ok {
+			return c.unmarshalProto(data, m)
+		}
+	}
+
+	return sonic.Unmarshal(data, v)
+}
+
+func (c codec) unmarshalProto(data []byte, m proto.Message) error {
+	if !m.ProtoReflect().IsValid() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(m)}
+	}
+
+	if c.unmarshalOptions != nil {
+		return c.unmarshalOptions.Unmarshal(data, m)
+	}
+
+	return UnmarshalOptions.Unmarshal(data, m)
+}
@@ -0,0 +1,76 @@
+package proto
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/dizzrt/ellie/encoding"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	_ encoding.Codec        = (*codec)(nil)
+	_ encoding.ContentTyper = (*codec)(nil)
+)
+
+const Name = "proto"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+type codec struct{}
+
+func (codec) Name() string {
+	return Name
+}
+
+func (codec) ContentTypes() []string {
+	return []string{
+		"application/protobuf",
+		"application/x-protobuf",
+	}
+}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal, message is %T, want proto.Message", v)
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	msg, err := getProtoMessage(v)
+	if err != nil {
+		return err
+	}
+
+	return proto.Unmarshal(data, msg)
+}
+
+func getProtoMessage(v any) (proto.Message, error) {
+	if msg, ok := v.(proto.Message); ok {
+		return msg, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("failed to unmarshal, message is %T, want proto.Message", v)
+	}
+
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		if msg, ok := rv.Interface().(proto.Message); ok {
+			return msg, nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	return nil, fmt.Errorf("failed to unmarshal, message is %T, want proto.Message", v)
+}
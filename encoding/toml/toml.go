@@ -0,0 +1,70 @@
+package toml
+
+import (
+	"github.com/bytedance/sonic"
+	"github.com/dizzrt/ellie/encoding"
+	"github.com/pelletier/go-toml/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	_ encoding.Codec        = (*codec)(nil)
+	_ encoding.ContentTyper = (*codec)(nil)
+)
+
+const Name = "toml"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+type codec struct{}
+
+func (codec) Name() string {
+	return Name
+}
+
+func (codec) ContentTypes() []string {
+	return []string{
+		"application/toml",
+	}
+}
+
+// Marshal writes proto messages from their protojson object, decoded into a
+// map as the root of a toml document must be a table.
+func (codec) Marshal(v any) ([]byte, error) {
+	if msg, ok := v.(proto.Message); ok {
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		var generic map[string]any
+		if err := sonic.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+
+		v = generic
+	}
+
+	return toml.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	if msg, ok := v.(proto.Message); ok {
+		var generic map[string]any
+		if err := toml.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+
+		raw, err := sonic.Marshal(generic)
+		if err != nil {
+			return err
+		}
+
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(raw, msg)
+	}
+
+	return toml.Unmarshal(data, v)
+}
@@ -0,0 +1,42 @@
+package xml
+
+import (
+	"encoding/xml"
+
+	"github.com/dizzrt/ellie/encoding"
+)
+
+var (
+	_ encoding.Codec        = (*codec)(nil)
+	_ encoding.ContentTyper = (*codec)(nil)
+)
+
+const Name = "xml"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+type codec struct{}
+
+func (codec) Name() string {
+	return Name
+}
+
+func (codec) ContentTypes() []string {
+	return []string{
+		"application/xml",
+		"text/xml",
+	}
+}
+
+// Marshal uses encoding/xml for every value. Unlike yaml and toml there is no
+// protojson bridge, a json object has no canonical xml form, so proto messages
+// are written with the Go names of their exported fields.
+func (codec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
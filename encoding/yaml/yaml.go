@@ -0,0 +1,72 @@
+package yaml
+
+import (
+	"github.com/bytedance/sonic"
+	"github.com/dizzrt/ellie/encoding"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ encoding.Codec        = (*codec)(nil)
+	_ encoding.ContentTyper = (*codec)(nil)
+)
+
+const Name = "yaml"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+type codec struct{}
+
+func (codec) Name() string {
+	return Name
+}
+
+func (codec) ContentTypes() []string {
+	return []string{
+		"application/yaml",
+		"application/x-yaml",
+		"text/yaml",
+	}
+}
+
+// Marshal writes proto messages as their protojson document, yaml being a
+// superset of json the output uses the same lowerCamelCase names.
+func (codec) Marshal(v any) ([]byte, error) {
+	if msg, ok := v.(proto.Message); ok {
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		var generic any
+		if err := sonic.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+
+		v = generic
+	}
+
+	return yaml.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	if msg, ok := v.(proto.Message); ok {
+		var generic any
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+
+		raw, err := sonic.Marshal(generic)
+		if err != nil {
+			return err
+		}
+
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(raw, msg)
+	}
+
+	return yaml.Unmarshal(data, v)
+}
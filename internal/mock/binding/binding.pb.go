@@ -0,0 +1,469 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v6.32.0
+// source: binding.proto
+
+package binding
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Status int32
+
+const (
+	Status_STATUS_UNSPECIFIED Status = 0
+	Status_STATUS_ACTIVE      Status = 1
+	Status_STATUS_DISABLED    Status = 2
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_ACTIVE",
+		2: "STATUS_DISABLED",
+	}
+	Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_ACTIVE":      1,
+		"STATUS_DISABLED":    2,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_binding_proto_enumTypes[0].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_binding_proto_enumTypes[0]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_binding_proto_rawDescGZIP(), []int{0}
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_binding_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_binding_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_binding_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+type BindRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Name     string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Id       int64                  `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Size     uint64                 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Enabled  bool                   `protobuf:"varint,4,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Score    float64                `protobuf:"fixed64,5,opt,name=score,proto3" json:"score,omitempty"`
+	Data     []byte                 `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	Status   Status                 `protobuf:"varint,7,opt,name=status,proto3,enum=binding.Status" json:"status,omitempty"`
+	Tags     []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	Statuses []Status               `protobuf:"varint,9,rep,packed,name=statuses,proto3,enum=binding.Status" json:"statuses,omitempty"`
+	Labels   map[string]string      `protobuf:"bytes,10,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	User     *User                  `protobuf:"bytes,11,opt,name=user,proto3" json:"user,omitempty"`
+	// Types that are valid to be assigned to Filter:
+	//
+	//	*BindRequest_Keyword
+	//	*BindRequest_Category
+	Filter        isBindRequest_Filter    `protobuf_oneof:"filter"`
+	CreateTime    *timestamppb.Timestamp  `protobuf:"bytes,14,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	Timeout       *durationpb.Duration    `protobuf:"bytes,15,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Nickname      *wrapperspb.StringValue `protobuf:"bytes,16,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	Limit         *wrapperspb.Int64Value  `protobuf:"bytes,17,opt,name=limit,proto3" json:"limit,omitempty"`
+	UpdateMask    *fieldmaskpb.FieldMask  `protobuf:"bytes,18,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	Extra         *structpb.Struct        `protobuf:"bytes,19,opt,name=extra,proto3" json:"extra,omitempty"`
+	Cursor        *string                 `protobuf:"bytes,20,opt,name=cursor,proto3,oneof" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BindRequest) Reset() {
+	*x = BindRequest{}
+	mi := &file_binding_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BindRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BindRequest) ProtoMessage() {}
+
+func (x *BindRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_binding_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BindRequest.ProtoReflect.Descriptor instead.
+func (*BindRequest) Descriptor() ([]byte, []int) {
+	return file_binding_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BindRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *BindRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BindRequest) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *BindRequest) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *BindRequest) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *BindRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *BindRequest) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *BindRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *BindRequest) GetStatuses() []Status {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *BindRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *BindRequest) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *BindRequest) GetFilter() isBindRequest_Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *BindRequest) GetKeyword() string {
+	if x != nil {
+		if x, ok := x.Filter.(*BindRequest_Keyword); ok {
+			return x.Keyword
+		}
+	}
+	return ""
+}
+
+func (x *BindRequest) GetCategory() int32 {
+	if x != nil {
+		if x, ok := x.Filter.(*BindRequest_Category); ok {
+			return x.Category
+		}
+	}
+	return 0
+}
+
+func (x *BindRequest) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *BindRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *BindRequest) GetNickname() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Nickname
+	}
+	return nil
+}
+
+func (x *BindRequest) GetLimit() *wrapperspb.Int64Value {
+	if x != nil {
+		return x.Limit
+	}
+	return nil
+}
+
+func (x *BindRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *BindRequest) GetExtra() *structpb.Struct {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+func (x *BindRequest) GetCursor() string {
+	if x != nil && x.Cursor != nil {
+		return *x.Cursor
+	}
+	return ""
+}
+
+type isBindRequest_Filter interface {
+	isBindRequest_Filter()
+}
+
+type BindRequest_Keyword struct {
+	Keyword string `protobuf:"bytes,12,opt,name=keyword,proto3,oneof"`
+}
+
+type BindRequest_Category struct {
+	Category int32 `protobuf:"varint,13,opt,name=category,proto3,oneof"`
+}
+
+func (*BindRequest_Keyword) isBindRequest_Filter() {}
+
+func (*BindRequest_Category) isBindRequest_Filter() {}
+
+var File_binding_proto protoreflect.FileDescriptor
+
+const file_binding_proto_rawDesc = "" +
+	"\n" +
+	"\rbinding.proto\x12\abinding\x1a\x1egoogle/protobuf/duration.proto\x1a google/protobuf/field_mask.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/wrappers.proto\"9\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\"\xc2\x06\n" +
+	"\vBindRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x04R\x04size\x12\x18\n" +
+	"\aenabled\x18\x04 \x01(\bR\aenabled\x12\x14\n" +
+	"\x05score\x18\x05 \x01(\x01R\x05score\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\fR\x04data\x12'\n" +
+	"\x06status\x18\a \x01(\x0e2\x0f.binding.StatusR\x06status\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12+\n" +
+	"\bstatuses\x18\t \x03(\x0e2\x0f.binding.StatusR\bstatuses\x128\n" +
+	"\x06labels\x18\n" +
+	" \x03(\v2 .binding.BindRequest.LabelsEntryR\x06labels\x12!\n" +
+	"\x04user\x18\v \x01(\v2\r.binding.UserR\x04user\x12\x1a\n" +
+	"\akeyword\x18\f \x01(\tH\x00R\akeyword\x12\x1c\n" +
+	"\bcategory\x18\r \x01(\x05H\x00R\bcategory\x12;\n" +
+	"\vcreate_time\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x123\n" +
+	"\atimeout\x18\x0f \x01(\v2\x19.google.protobuf.DurationR\atimeout\x128\n" +
+	"\bnickname\x18\x10 \x01(\v2\x1c.google.protobuf.StringValueR\bnickname\x121\n" +
+	"\x05limit\x18\x11 \x01(\v2\x1b.google.protobuf.Int64ValueR\x05limit\x12;\n" +
+	"\vupdate_mask\x18\x12 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12-\n" +
+	"\x05extra\x18\x13 \x01(\v2\x17.google.protobuf.StructR\x05extra\x12\x1b\n" +
+	"\x06cursor\x18\x14 \x01(\tH\x01R\x06cursor\x88\x01\x01\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\b\n" +
+	"\x06filterB\t\n" +
+	"\a_cursor*H\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSTATUS_ACTIVE\x10\x01\x12\x13\n" +
+	"\x0fSTATUS_DISABLED\x10\x02B/Z-github.com/dizzrt/ellie/internal/mock/bindingb\x06proto3"
+
+var (
+	file_binding_proto_rawDescOnce sync.Once
+	file_binding_proto_rawDescData []byte
+)
+
+func file_binding_proto_rawDescGZIP() []byte {
+	file_binding_proto_rawDescOnce.Do(func() {
+		file_binding_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_binding_proto_rawDesc), len(file_binding_proto_rawDesc)))
+	})
+	return file_binding_proto_rawDescData
+}
+
+var file_binding_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_binding_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_binding_proto_goTypes = []any{
+	(Status)(0),                    // 0: binding.Status
+	(*User)(nil),                   // 1: binding.User
+	(*BindRequest)(nil),            // 2: binding.BindRequest
+	nil,                            // 3: binding.BindRequest.LabelsEntry
+	(*timestamppb.Timestamp)(nil),  // 4: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),    // 5: google.protobuf.Duration
+	(*wrapperspb.StringValue)(nil), // 6: google.protobuf.StringValue
+	(*wrapperspb.Int64Value)(nil),  // 7: google.protobuf.Int64Value
+	(*fieldmaskpb.FieldMask)(nil),  // 8: google.protobuf.FieldMask
+	(*structpb.Struct)(nil),        // 9: google.protobuf.Struct
+}
+var file_binding_proto_depIdxs = []int32{
+	0,  // 0: binding.BindRequest.status:type_name -> binding.Status
+	0,  // 1: binding.BindRequest.statuses:type_name -> binding.Status
+	3,  // 2: binding.BindRequest.labels:type_name -> binding.BindRequest.LabelsEntry
+	1,  // 3: binding.BindRequest.user:type_name -> binding.User
+	4,  // 4: binding.BindRequest.create_time:type_name -> google.protobuf.Timestamp
+	5,  // 5: binding.BindRequest.timeout:type_name -> google.protobuf.Duration
+	6,  // 6: binding.BindRequest.nickname:type_name -> google.protobuf.StringValue
+	7,  // 7: binding.BindRequest.limit:type_name -> google.protobuf.Int64Value
+	8,  // 8: binding.BindRequest.update_mask:type_name -> google.protobuf.FieldMask
+	9,  // 9: binding.BindRequest.extra:type_name -> google.protobuf.Struct
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_binding_proto_init() }
+func file_binding_proto_init() {
+	if File_binding_proto != nil {
+		return
+	}
+	file_binding_proto_msgTypes[1].OneofWrappers = []any{
+		(*BindRequest_Keyword)(nil),
+		(*BindRequest_Category)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_binding_proto_rawDesc), len(file_binding_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_binding_proto_goTypes,
+		DependencyIndexes: file_binding_proto_depIdxs,
+		EnumInfos:         file_binding_proto_enumTypes,
+		MessageInfos:      file_binding_proto_msgTypes,
+	}.Build()
+	File_binding_proto = out.File
+	file_binding_proto_goTypes = nil
+	file_binding_proto_depIdxs = nil
+}
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-ellie-go-http. DO NOT EDIT.
 // versions:
-// - protoc-gen-ellie-go-http v1.1.3
+// - protoc-gen-ellie-go-http v1.1.4
 // - protoc             v6.32.0
 // source: ping.proto
 
@@ -11,7 +11,6 @@
 	log "github.com/dizzrt/ellie/log"
 	http "github.com/dizzrt/ellie/transport/http"
 	ginx "github.com/dizzrt/ellie/transport/http/ginx"
-	gin "github.com/gin-gonic/gin"
 	otel "go.opentelemetry.io/otel"
 	attribute "go.opentelemetry.io/otel/attribute"
 	propagation "go.opentelemetry.io/otel/propagation"
@@ -20,7 +19,6 @@
 )
 
 var _ = new(context.Context)
-var _ = new(gin.Engine)
 var _ = new(ginx.Ginx)
 var _ = new(http.Server)
 var _ = otel.Tracer
@@ -41,20 +39,18 @@ type PingServiceHTTPServer interface {
 }
 
 func RegisterPingServiceHTTPServer(hs *http.Server, srv PingServiceHTTPServer) {
-	r := hs.Engine()
-	r.GET("/ping", _ping_PingService_GET_Ping_HTTP_Handler(hs, srv))
-	r.POST("/hello/:name", _ping_PingService_POST_Hello_HTTP_Handler(hs, srv))
+	hs.Route("GET", "/ping", OperationPingServicePing, _ping_PingService_GET_Ping_HTTP_Handler(hs, srv))
+	hs.Route("POST", "/hello/:name", OperationPingServiceHello, _ping_PingService_POST_Hello_HTTP_Handler(hs, srv))
 }
-func _ping_PingService_GET_Ping_HTTP_Handler(hs *http.Server, srv PingServiceHTTPServer) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
+func _ping_PingService_GET_Ping_HTTP_Handler(hs *http.Server, srv PingServiceHTTPServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, greq *http.Request) {
 		var req PingRequest
-		if err := ginx.DecodeRequest(ctx, &req); err != nil {
-			ctx.JSON(http.StatusBadRequest, hs.WrapHTTPResponse(nil, err))
-			ctx.Abort()
+		greq, err := ginx.DecodeHTTPRequest(greq, &req, ginx.Body(""))
+		if err != nil {
+			hs.WriteResponse(w, greq, nil, err)
 			return
 		}
 
-		greq := ctx.Request
 		rctx := greq.Context()
 		rctx = log.ExtractFromTextMapCarrier(rctx, propagation.HeaderCarrier(greq.Header))
 		attributes := []attribute.KeyValue{
@@ -74,27 +70,20 @@ func _ping_PingService_GET_Ping_HTTP_Handler(hs *http.Server, srv PingServiceHTT
 		rctx = log.WithTraceID(rctx, sctx.TraceID().String())
 		rctx = log.WithSpanID(rctx, sctx.SpanID().String())
 
+		greq = greq.WithContext(rctx)
 		res, err := srv.Ping(rctx, &req)
-		ctx.Request = ctx.Request.WithContext(rctx)
-		if err != nil {
-			ctx.JSON(http.HTTPStatusCodeFromError(err), hs.WrapHTTPResponse(res, err))
-			ctx.Abort()
-			return
-		}
-
-		hs.EncodeResponse(ctx, res, err)
+		hs.WriteResponse(w, greq, res, err)
 	}
 }
-func _ping_PingService_POST_Hello_HTTP_Handler(hs *http.Server, srv PingServiceHTTPServer) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
+func _ping_PingService_POST_Hello_HTTP_Handler(hs *http.Server, srv PingServiceHTTPServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, greq *http.Request) {
 		var req HelloRequest
-		if err := ginx.DecodeRequest(ctx, &req); err != nil {
-			ctx.JSON(http.StatusBadRequest, hs.WrapHTTPResponse(nil, err))
-			ctx.Abort()
+		greq, err := ginx.DecodeHTTPRequest(greq, &req, ginx.Body("*"))
+		if err != nil {
+			hs.WriteResponse(w, greq, nil, err)
 			return
 		}
 
-		greq := ctx.Request
 		rctx := greq.Context()
 		rctx = log.ExtractFromTextMapCarrier(rctx, propagation.HeaderCarrier(greq.Header))
 		attributes := []attribute.KeyValue{
@@ -114,14 +103,51 @@ func _ping_PingService_POST_Hello_HTTP_Handler(hs *http.Server, srv PingServiceH
 		rctx = log.WithTraceID(rctx, sctx.TraceID().String())
 		rctx = log.WithSpanID(rctx, sctx.SpanID().String())
 
+		greq = greq.WithContext(rctx)
 		res, err := srv.Hello(rctx, &req)
-		ctx.Request = ctx.Request.WithContext(rctx)
-		if err != nil {
-			ctx.JSON(http.HTTPStatusCodeFromError(err), hs.WrapHTTPResponse(res, err))
-			ctx.Abort()
-			return
-		}
+		hs.WriteResponse(w, greq, res, err)
+	}
+}
+
+type PingServiceHTTPClient interface {
+	Ping(ctx context.Context, req *PingRequest, opts ...http.CallOption) (*PingResponse, error)
+	Hello(ctx context.Context, req *HelloRequest, opts ...http.CallOption) (*HelloResponse, error)
+}
 
-		hs.EncodeResponse(ctx, res, err)
+type PingServiceHTTPClientImpl struct {
+	cc *http.Client
+}
+
+func NewPingServiceHTTPClient(client *http.Client) PingServiceHTTPClient {
+	return &PingServiceHTTPClientImpl{cc: client}
+}
+
+func (c *PingServiceHTTPClientImpl) Ping(ctx context.Context, in *PingRequest, opts ...http.CallOption) (*PingResponse, error) {
+	path, err := http.EncodeURL("/ping", in, "")
+	if err != nil {
+		return nil, err
 	}
+
+	var out PingResponse
+	err = c.cc.Invoke(ctx, "GET", path, nil, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (c *PingServiceHTTPClientImpl) Hello(ctx context.Context, in *HelloRequest, opts ...http.CallOption) (*HelloResponse, error) {
+	path, err := http.EncodeURL("/hello/{name}", in, "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var out HelloResponse
+	err = c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
 }
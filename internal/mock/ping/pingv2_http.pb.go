@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-ellie-go-http. DO NOT EDIT.
 // versions:
-// - protoc-gen-ellie-go-http v1.1.3
+// - protoc-gen-ellie-go-http v1.1.4
 // - protoc             v6.32.0
 // source: pingv2.proto
 
@@ -11,7 +11,6 @@
 	log "github.com/dizzrt/ellie/log"
 	http "github.com/dizzrt/ellie/transport/http"
 	ginx "github.com/dizzrt/ellie/transport/http/ginx"
-	gin "github.com/gin-gonic/gin"
 	otel "go.opentelemetry.io/otel"
 	attribute "go.opentelemetry.io/otel/attribute"
 	propagation "go.opentelemetry.io/otel/propagation"
@@ -20,7 +19,6 @@
 )
 
 var _ = new(context.Context)
-var _ = new(gin.Engine)
 var _ = new(ginx.Ginx)
 var _ = new(http.Server)
 var _ = otel.Tracer
@@ -31,26 +29,24 @@
 var _ = v1_21_0.HTTPRequestMethodKey
 
 const TRACER_NAME_PINGV2 = "github.com/dizzrt/ellie/internal/mock/ping"
-const OperationPingV2Ping = "/Ping_v2/Ping"
+const OperationPingV2Ping = "/PingV2/Ping"
 
 type PingV2HTTPServer interface {
 	Ping(context.Context, *PingV2Request) (*PingV2Response, error)
 }
 
 func RegisterPingV2HTTPServer(hs *http.Server, srv PingV2HTTPServer) {
-	r := hs.Engine()
-	r.POST("/v2/ping", _pingv2_PingV2_POST_Ping_HTTP_Handler(hs, srv))
+	hs.Route("POST", "/v2/ping", OperationPingV2Ping, _pingv2_PingV2_POST_Ping_HTTP_Handler(hs, srv))
 }
-func _pingv2_PingV2_POST_Ping_HTTP_Handler(hs *http.Server, srv PingV2HTTPServer) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
+func _pingv2_PingV2_POST_Ping_HTTP_Handler(hs *http.Server, srv PingV2HTTPServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, greq *http.Request) {
 		var req PingV2Request
-		if err := ginx.DecodeRequest(ctx, &req); err != nil {
-			ctx.JSON(http.StatusBadRequest, hs.WrapHTTPResponse(nil, err))
-			ctx.Abort()
+		greq, err := ginx.DecodeHTTPRequest(greq, &req, ginx.Body("*"))
+		if err != nil {
+			hs.WriteResponse(w, greq, nil, err)
 			return
 		}
 
-		greq := ctx.Request
 		rctx := greq.Context()
 		rctx = log.ExtractFromTextMapCarrier(rctx, propagation.HeaderCarrier(greq.Header))
 		attributes := []attribute.KeyValue{
@@ -70,14 +66,35 @@ func _pingv2_PingV2_POST_Ping_HTTP_Handler(hs *http.Server, srv PingV2HTTPServer
 		rctx = log.WithTraceID(rctx, sctx.TraceID().String())
 		rctx = log.WithSpanID(rctx, sctx.SpanID().String())
 
+		greq = greq.WithContext(rctx)
 		res, err := srv.Ping(rctx, &req)
-		ctx.Request = ctx.Request.WithContext(rctx)
-		if err != nil {
-			ctx.JSON(http.HTTPStatusCodeFromError(err), hs.WrapHTTPResponse(res, err))
-			ctx.Abort()
-			return
-		}
+		hs.WriteResponse(w, greq, res, err)
+	}
+}
+
+type PingV2HTTPClient interface {
+	Ping(ctx context.Context, req *PingV2Request, opts ...http.CallOption) (*PingV2Response, error)
+}
 
-		hs.EncodeResponse(ctx, res, err)
+type PingV2HTTPClientImpl struct {
+	cc *http.Client
+}
+
+func NewPingV2HTTPClient(client *http.Client) PingV2HTTPClient {
+	return &PingV2HTTPClientImpl{cc: client}
+}
+
+func (c *PingV2HTTPClientImpl) Ping(ctx context.Context, in *PingV2Request, opts ...http.CallOption) (*PingV2Response, error) {
+	path, err := http.EncodeURL("/v2/ping", in, "*")
+	if err != nil {
+		return nil, err
 	}
+
+	var out PingV2Response
+	err = c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
 }
@@ -2,8 +2,13 @@
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync/atomic"
 
 	"github.com/dizzrt/ellie/log/logid"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/propagation"
 )
 
@@ -22,6 +27,112 @@ type _CONTEXT_KEY_TRACEPARENT struct{
 	{_CONTEXT_KEY_TRACEPARENT{}, "traceparent"},
 }
 
+type baggageField struct {
+	key  string // baggage member key
+	name string // log field name
+}
+
+// baggage fields sorted by member key, so they are logged in a stable order
+var baggageFields atomic.Pointer[[]baggageField]
+
+// SetBaggageFields replaces the baggage members added to every Ctx* log line,
+// keyed by baggage member key with the log field name as value.
+func SetBaggageFields(fields map[string]string) {
+	baggageFields.Store(sortBaggageFields(fields))
+}
+
+// AddBaggageFields adds baggage members that are logged under their own key.
+func AddBaggageFields(keys ...string) {
+	for {
+		old := baggageFields.Load()
+
+		mappings := toBaggageMappings(old)
+		if mappings == nil {
+			mappings = make(map[string]string, len(keys))
+		}
+
+		for _, key := range keys {
+			mappings[key] = key
+		}
+
+		if baggageFields.CompareAndSwap(old, sortBaggageFields(mappings)) {
+			return
+		}
+	}
+}
+
+func BaggageFields() map[string]string {
+	return toBaggageMappings(baggageFields.Load())
+}
+
+func sortBaggageFields(mappings map[string]string) *[]baggageField {
+	fields := make([]baggageField, 0, len(mappings))
+	for _, key := range slices.Sorted(maps.Keys(mappings)) {
+		fields = append(fields, baggageField{key: key, name: mappings[key]})
+	}
+
+	return &fields
+}
+
+func toBaggageMappings(fields *[]baggageField) map[string]string {
+	if fields == nil {
+		return nil
+	}
+
+	mappings := make(map[string]string, len(*fields))
+	for _, f := range *fields {
+		mappings[f.key] = f.name
+	}
+
+	return mappings
+}
+
+// WithBaggage adds key-value pairs as baggage members to ctx, they are
+// propagated downstream by the otel Baggage propagator.
+func WithBaggage(ctx context.Context, kvs ...string) (context.Context, error) {
+	if len(kvs)&1 == 1 {
+		return ctx, fmt.Errorf("baggage kvs must appear in pairs: %v", kvs)
+	}
+
+	bag := baggage.FromContext(ctx)
+	for i := 0; i < len(kvs); i += 2 {
+		member, err := baggage.NewMemberRaw(kvs[i], kvs[i+1])
+		if err != nil {
+			return ctx, err
+		}
+
+		if bag, err = bag.SetMember(member); err != nil {
+			return ctx, err
+		}
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag), nil
+}
+
+func BaggageValue(ctx context.Context, key string) string {
+	return baggage.FromContext(ctx).Member(key).Value()
+}
+
+func fromBaggage(ctx context.Context, kvs []any) []any {
+	fields := baggageFields.Load()
+	if fields == nil || len(*fields) == 0 {
+		return kvs
+	}
+
+	bag := baggage.FromContext(ctx)
+	if bag.Len() == 0 {
+		return kvs
+	}
+
+	for _, f := range *fields {
+		if v := bag.Member(f.key).Value(); v != "" {
+			kvs = append(kvs, f.name, v)
+		}
+	}
+
+	return kvs
+}
+
 func LogIDFromContext(ctx context.Context) string {
 	if logID, ok := ctx.Value(_CONTEXT_KEY_LOG_ID{}).(string); ok {
 		return logID
@@ -66,7 +177,7 @@ func WithTraceID(ctx context.Context, traceID string) context.Context {
 	return context.WithValue(ctx, _CONTEXT_KEY_TRACE_ID{}, traceID)
 }
 
-// extract log_id and traceparent and inject them into context
+// extract log_id, traceparent and baggage and inject them into context
 func ExtractFromTextMapCarrier(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
 	if logID := LogIDFromContext(ctx); logID == "" {
 		if logID := carrier.Get("log.id"); logID != "" {
@@ -85,5 +196,10 @@ func ExtractFromTextMapCarrier(ctx context.Context, carrier propagation.TextMapC
 		ctx = context.WithValue(ctx, _CONTEXT_KEY_TRACEPARENT{}, traceparent)
 	}
 
+	// baggage is needed by log fields even if no otel propagator is installed
+	if baggage.FromContext(ctx).Len() == 0 {
+		ctx = propagation.Baggage{}.Extract(ctx, carrier)
+	}
+
 	return ctx
 }
@@ -138,7 +138,7 @@ func fromCtx(ctx context.Context, kvs ...any) []any {
 		}
 	}
 
-	return kvs
+	return fromBaggage(ctx, kvs)
 }
 
 func CtxDebug(ctx context.Context, a ...any) {
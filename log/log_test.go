@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"slices"
 	"testing"
 
 	"github.com/dizzrt/ellie/log/zlog"
@@ -80,3 +81,58 @@ func TestGlobalCtxLog(t *testing.T) {
 
 	Sync()
 }
+
+func TestBaggageFields(t *testing.T) {
+	defer SetBaggageFields(nil)
+
+	ctx, err := WithBaggage(context.Background(), "tenant", "acme", "user_id", "42", "ignored", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetBaggageFields(map[string]string{"tenant": "tenant_name"})
+	AddBaggageFields("user_id")
+
+	kvs := fromCtx(ctx, DefaultMessageKey, "hello")
+	fields := make(map[string]any)
+	for i := 0; i < len(kvs); i += 2 {
+		fields[kvs[i].(string)] = kvs[i+1]
+	}
+
+	if fields["tenant_name"] != "acme" {
+		t.Errorf("tenant_name = %v, want acme", fields["tenant_name"])
+	}
+
+	if fields["user_id"] != "42" {
+		t.Errorf("user_id = %v, want 42", fields["user_id"])
+	}
+
+	if _, ok := fields["ignored"]; ok {
+		t.Error("unmapped baggage member should not be logged")
+	}
+
+	if BaggageValue(ctx, "ignored") != "x" {
+		t.Error("baggage member should still propagate")
+	}
+
+	if _, err := WithBaggage(ctx, "odd"); err == nil {
+		t.Error("expected error for odd kvs")
+	}
+}
+
+func TestBaggageFieldsOrder(t *testing.T) {
+	defer SetBaggageFields(nil)
+
+	ctx, err := WithBaggage(context.Background(), "a", "1", "b", "2", "c", "3", "d", "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetBaggageFields(map[string]string{"d": "d", "c": "c", "b": "b", "a": "a"})
+	want := []any{"a", "1", "b", "2", "c", "3", "d", "4"}
+	for range 10 {
+		if got := fromBaggage(ctx, nil); !slices.Equal(got, want) {
+			t.Fatalf("fromBaggage = %v, want %v", got, want)
+		}
+	}
+}
@@ -1,10 +1,29 @@
 package tracing
 
+import (
+	"crypto/tls"
+	"time"
+
+	trace_sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
 type _EndpointType string
 
 const (
 	EndpointType_GRPC _EndpointType = "grpc"
 	EndpointType_HTTP _EndpointType = "http"
+
+	// local exporters, no collector required
+	EndpointType_Stdout _EndpointType = "stdout"
+	EndpointType_File   _EndpointType = "file"
+	EndpointType_Memory _EndpointType = "memory"
+)
+
+type _Compression string
+
+const (
+	Compression_None _Compression = "none"
+	Compression_Gzip _Compression = "gzip"
 )
 
 type config struct {
@@ -14,6 +33,21 @@ type config struct {
 	endpoint       string
 	endpointType   _EndpointType
 	insecure       bool
+	tlsConf        *tls.Config
+	headers        map[string]string
+	compression    _Compression
+	recorder       *MemoryRecorder
+
+	// sampling
+	sampler       trace_sdk.Sampler
+	sampleRatio   float64
+	samplingRules []SamplingRule
+
+	// batch span processor
+	batchTimeout       time.Duration
+	exportTimeout      time.Duration
+	maxQueueSize       int
+	maxExportBatchSize int
 }
 
 type Option func(*config)
@@ -36,6 +70,7 @@ func Metadata(metadata map[string]string) Option {
 	}
 }
 
+// Endpoint sets the collector address, or the output file path for EndpointType_File.
 func Endpoint(endpoint string) Option {
 	return func(opts *config) {
 		opts.endpoint = endpoint
@@ -54,11 +89,101 @@ func Insecure(insecure bool) Option {
 	}
 }
 
+// Recorder sets the recorder spans are exported to with EndpointType_Memory.
+func Recorder(recorder *MemoryRecorder) Option {
+	return func(opts *config) {
+		opts.recorder = recorder
+	}
+}
+
+// TLSConfig sets the client TLS config used by the OTLP exporter, it only
+// takes effect when Insecure is false.
+func TLSConfig(tlsConf *tls.Config) Option {
+	return func(opts *config) {
+		opts.tlsConf = tlsConf
+	}
+}
+
+// Headers sets extra headers (e.g. authorization) sent with every export request.
+func Headers(headers map[string]string) Option {
+	return func(opts *config) {
+		opts.headers = headers
+	}
+}
+
+func Compression(compression _Compression) Option {
+	return func(opts *config) {
+		opts.compression = compression
+	}
+}
+
+// Sampler overrides the sampler built from SampleRatio and SamplingRules.
+func Sampler(sampler trace_sdk.Sampler) Option {
+	return func(opts *config) {
+		opts.sampler = sampler
+	}
+}
+
+// SampleRatio sets the ratio of root spans to sample, spans with a parent
+// follow the parent's sampling decision.
+func SampleRatio(ratio float64) Option {
+	return func(opts *config) {
+		opts.sampleRatio = ratio
+	}
+}
+
+// SamplingRules sets per operation sample ratios, the first matching rule
+// wins and unmatched operations fall back to SampleRatio.
+func SamplingRules(rules ...SamplingRule) Option {
+	return func(opts *config) {
+		opts.samplingRules = append(opts.samplingRules, rules...)
+	}
+}
+
+func BatchTimeout(timeout time.Duration) Option {
+	return func(opts *config) {
+		opts.batchTimeout = timeout
+	}
+}
+
+func ExportTimeout(timeout time.Duration) Option {
+	return func(opts *config) {
+		opts.exportTimeout = timeout
+	}
+}
+
+func MaxQueueSize(size int) Option {
+	return func(opts *config) {
+		opts.maxQueueSize = size
+	}
+}
+
+func MaxExportBatchSize(size int) Option {
+	return func(opts *config) {
+		opts.maxExportBatchSize = size
+	}
+}
+
 func ParseEndpointType(endpointType string) _EndpointType {
 	switch endpointType {
 	case "http":
 		return EndpointType_HTTP
+	case "stdout":
+		return EndpointType_Stdout
+	case "file":
+		return EndpointType_File
+	case "memory":
+		return EndpointType_Memory
 	default:
 		return EndpointType_GRPC
 	}
 }
+
+func ParseCompression(compression string) _Compression {
+	switch compression {
+	case "gzip":
+		return Compression_Gzip
+	default:
+		return Compression_None
+	}
+}
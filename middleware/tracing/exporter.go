@@ -0,0 +1,184 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	trace_sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	_ trace_sdk.SpanExporter = (*fileExporter)(nil)
+	_ trace_sdk.SpanExporter = (*MemoryRecorder)(nil)
+)
+
+var defaultMemoryRecorder = NewMemoryRecorder()
+
+func newExporter(ctx context.Context, conf *config) (trace_sdk.SpanExporter, error) {
+	switch conf.endpointType {
+	case EndpointType_GRPC:
+		return otlptracegrpc.New(ctx, grpcExporterOptions(conf)...)
+	case EndpointType_HTTP:
+		return otlptracehttp.New(ctx, httpExporterOptions(conf)...)
+	case EndpointType_Stdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case EndpointType_File:
+		return newFileExporter(conf.endpoint)
+	case EndpointType_Memory:
+		if conf.recorder != nil {
+			return conf.recorder, nil
+		}
+
+		return defaultMemoryRecorder, nil
+	default:
+		return nil, fmt.Errorf("invalid endpoint type: %s", conf.endpointType)
+	}
+}
+
+// isLocalEndpointType reports whether spans are exported without a collector,
+// such exporters are driven synchronously so spans are visible right away.
+func isLocalEndpointType(endpointType _EndpointType) bool {
+	switch endpointType {
+	case EndpointType_Stdout, EndpointType_File, EndpointType_Memory:
+		return true
+	default:
+		return false
+	}
+}
+
+// fileExporter writes spans as newline-delimited JSON to the file at path.
+type fileExporter struct {
+	*stdouttrace.Exporter
+	file *os.File
+}
+
+func newFileExporter(path string) (*fileExporter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file exporter requires a file path as endpoint")
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &fileExporter{
+		Exporter: exporter,
+		file:     file,
+	}, nil
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	if err := e.Exporter.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	return e.file.Close()
+}
+
+// MemoryRecorder keeps exported spans in memory, it is meant for tests.
+type MemoryRecorder struct {
+	mu    sync.RWMutex
+	spans []trace_sdk.ReadOnlySpan
+}
+
+func NewMemoryRecorder() *MemoryRecorder {
+	return &MemoryRecorder{}
+}
+
+// DefaultMemoryRecorder returns the recorder used by EndpointType_Memory when
+// no recorder is set with the Recorder option.
+func DefaultMemoryRecorder() *MemoryRecorder {
+	return defaultMemoryRecorder
+}
+
+func (r *MemoryRecorder) ExportSpans(_ context.Context, spans []trace_sdk.ReadOnlySpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *MemoryRecorder) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// Spans returns all recorded spans in the order they ended.
+func (r *MemoryRecorder) Spans() []trace_sdk.ReadOnlySpan {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.spans)
+}
+
+func (r *MemoryRecorder) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.spans)
+}
+
+// Filter returns the recorded spans for which fn returns true.
+func (r *MemoryRecorder) Filter(fn func(trace_sdk.ReadOnlySpan) bool) []trace_sdk.ReadOnlySpan {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]trace_sdk.ReadOnlySpan, 0)
+	for _, span := range r.spans {
+		if fn(span) {
+			res = append(res, span)
+		}
+	}
+
+	return res
+}
+
+func (r *MemoryRecorder) SpansByName(name string) []trace_sdk.ReadOnlySpan {
+	return r.Filter(func(span trace_sdk.ReadOnlySpan) bool {
+		return span.Name() == name
+	})
+}
+
+func (r *MemoryRecorder) SpansByTraceID(traceID trace.TraceID) []trace_sdk.ReadOnlySpan {
+	return r.Filter(func(span trace_sdk.ReadOnlySpan) bool {
+		return span.SpanContext().TraceID() == traceID
+	})
+}
+
+func (r *MemoryRecorder) SpansByKind(kind trace.SpanKind) []trace_sdk.ReadOnlySpan {
+	return r.Filter(func(span trace_sdk.ReadOnlySpan) bool {
+		return span.SpanKind() == kind
+	})
+}
+
+// FindSpan returns the first recorded span with the given name.
+func (r *MemoryRecorder) FindSpan(name string) (trace_sdk.ReadOnlySpan, bool) {
+	spans := r.SpansByName(name)
+	if len(spans) == 0 {
+		return nil, false
+	}
+
+	return spans[0], true
+}
+
+// Reset drops all recorded spans.
+func (r *MemoryRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = nil
+}
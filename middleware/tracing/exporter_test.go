@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func restoreGlobals(t *testing.T) {
+	tp := otel.GetTracerProvider()
+	propagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		InitializeWithCustomTracer(tp, propagator)
+	})
+}
+
+func TestMemoryExporter(t *testing.T) {
+	restoreGlobals(t)
+	ctx := context.Background()
+
+	recorder := NewMemoryRecorder()
+	_, shutdown, err := Initialize(ctx,
+		ServiceName("tracing-test"),
+		EndpointType(ParseEndpointType("memory")),
+		Recorder(recorder),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = shutdown(ctx) }()
+
+	tracer := otel.Tracer("tracing-test")
+	pctx, parent := tracer.Start(ctx, "parent", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := tracer.Start(pctx, "child", trace.WithSpanKind(trace.SpanKindClient))
+	child.End()
+	parent.End()
+
+	if recorder.Len() != 2 {
+		t.Fatalf("expected 2 spans, got %d", recorder.Len())
+	}
+
+	span, ok := recorder.FindSpan("child")
+	if !ok {
+		t.Fatal("child span not recorded")
+	}
+
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("child span is not linked to its parent")
+	}
+
+	if got := len(recorder.SpansByTraceID(parent.SpanContext().TraceID())); got != 2 {
+		t.Errorf("expected 2 spans in trace, got %d", got)
+	}
+
+	if got := len(recorder.SpansByKind(trace.SpanKindServer)); got != 1 {
+		t.Errorf("expected 1 server span, got %d", got)
+	}
+
+	recorder.Reset()
+	if recorder.Len() != 0 {
+		t.Errorf("expected no spans after reset, got %d", recorder.Len())
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	restoreGlobals(t)
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "traces.ndjson")
+	_, shutdown, err := Initialize(ctx,
+		ServiceName("tracing-test"),
+		EndpointType(EndpointType_File),
+		Endpoint(file),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := otel.Tracer("tracing-test")
+	for _, name := range []string{"a", "b", "c"} {
+		_, span := tracer.Start(ctx, name)
+		span.End()
+	}
+
+	if err := shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	names := make([]string, 0, 3)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var span struct {
+			Name string `json:"Name"`
+		}
+
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			t.Fatalf("invalid json line %q: %v", scanner.Text(), err)
+		}
+
+		names = append(names, span.Name)
+	}
+
+	if len(names) != 3 || names[0] != "a" || names[2] != "c" {
+		t.Errorf("unexpected spans in file: %v", names)
+	}
+}
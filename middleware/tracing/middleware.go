@@ -88,8 +88,37 @@ func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 
 func StreamServerInterceptor() grpc.StreamServerInterceptor {
 	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		// TODO: add tracing for stream
-		return handler(srv, ss)
+		ctx := ss.Context()
+		md, ok := metadata.FromIncomingContext(ctx)
+		if ok {
+			carrier := &metadataCarrier{md: md}
+			ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+			ctx = log.ExtractFromTextMapCarrier(ctx, carrier)
+		}
+
+		tracer := otel.Tracer(grpcServerTracerName)
+		spanName := path.Base(info.FullMethod)
+
+		ctx, span := tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		sctx := span.SpanContext()
+		ctx = log.WithTraceID(ctx, sctx.TraceID().String())
+		ctx = log.WithSpanID(ctx, sctx.SpanID().String())
+
+		span.SetAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", path.Dir(info.FullMethod)[1:]),
+			attribute.String("rpc.method", path.Base(info.FullMethod)),
+			attribute.Bool("rpc.grpc.client_stream", info.IsClientStream),
+			attribute.Bool("rpc.grpc.server_stream", info.IsServerStream),
+			attribute.String("log.id", log.LogIDFromContext(ctx)),
+		)
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx, span: span})
+		recordStatus(span, err)
+
+		return err
 	}
 }
 
@@ -133,10 +162,50 @@ func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
 	}
 }
 
+// StreamClientInterceptor traces client streams, the span ends when the
+// stream is released as described by grpc.ClientConn.NewStream. Cancel the
+// context of server streams that are not read until io.EOF.
 func StreamClientInterceptor() grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
-		// TODO: add tracing for stream
-		return streamer(ctx, desc, cc, method, opts...)
+		tracer := otel.Tracer(grpcClientTracerName)
+		spanName := path.Base(method)
+
+		ctx, span := tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindClient))
+
+		var logID string
+		if logID = log.LogIDFromContext(ctx); logID == "" {
+			logID = logid.Generate().String()
+		}
+
+		span.SetAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", path.Dir(method)[1:]),
+			attribute.String("rpc.method", path.Base(method)),
+			attribute.Bool("rpc.grpc.client_stream", desc.ClientStreams),
+			attribute.Bool("rpc.grpc.server_stream", desc.ServerStreams),
+			attribute.String("log.id", logID),
+		)
+
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if !ok {
+			md = metadata.New(nil)
+		} else {
+			md = md.Copy()
+		}
+
+		carrier := &metadataCarrier{md: md}
+		otel.GetTextMapPropagator().Inject(ctx, carrier)
+		md.Set("log.id", logID)
+
+		ctx = metadata.NewOutgoingContext(ctx, md)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			recordStatus(span, err)
+			span.End()
+			return nil, err
+		}
+
+		return newClientStream(ctx, cs, desc, span), nil
 	}
 }
 
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"fmt"
+	"strings"
+
+	trace_sdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var _ trace_sdk.Sampler = (*ruleSampler)(nil)
+
+// SamplingRule samples spans whose name matches Operation with Ratio.
+// Operation is either an exact span name or a prefix ending with "*",
+// e.g. "Ping" or "GET /health*".
+type SamplingRule struct {
+	Operation string
+	Ratio     float64
+}
+
+type rule struct {
+	SamplingRule
+	sampler trace_sdk.Sampler
+}
+
+func (r *rule) match(name string) bool {
+	if prefix, ok := strings.CutSuffix(r.Operation, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+
+	return name == r.Operation
+}
+
+type ruleSampler struct {
+	rules    []*rule
+	fallback trace_sdk.Sampler
+}
+
+func newRuleSampler(fallback trace_sdk.Sampler, rules ...SamplingRule) *ruleSampler {
+	rs := &ruleSampler{
+		rules:    make([]*rule, 0, len(rules)),
+		fallback: fallback,
+	}
+
+	for _, r := range rules {
+		rs.rules = append(rs.rules, &rule{
+			SamplingRule: r,
+			sampler:      trace_sdk.TraceIDRatioBased(r.Ratio),
+		})
+	}
+
+	return rs
+}
+
+func (rs *ruleSampler) ShouldSample(p trace_sdk.SamplingParameters) trace_sdk.SamplingResult {
+	for _, r := range rs.rules {
+		if r.match(p.Name) {
+			return r.sampler.ShouldSample(p)
+		}
+	}
+
+	return rs.fallback.ShouldSample(p)
+}
+
+func (rs *ruleSampler) Description() string {
+	rules := make([]string, 0, len(rs.rules))
+	for _, r := range rs.rules {
+		rules = append(rules, fmt.Sprintf("%s=%g", r.Operation, r.Ratio))
+	}
+
+	return fmt.Sprintf("RuleSampler{rules:[%s],fallback:%s}", strings.Join(rules, ","), rs.fallback.Description())
+}
+
+// buildSampler returns a parent based sampler whose root decision is made by
+// the per operation rules and the default ratio.
+func buildSampler(conf *config) trace_sdk.Sampler {
+	if conf.sampler != nil {
+		return conf.sampler
+	}
+
+	var root trace_sdk.Sampler = trace_sdk.TraceIDRatioBased(conf.sampleRatio)
+	if len(conf.samplingRules) > 0 {
+		root = newRuleSampler(root, conf.samplingRules...)
+	}
+
+	return trace_sdk.ParentBased(root)
+}
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	trace_sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRuleSampler(t *testing.T) {
+	sampler := buildSampler(&config{
+		sampleRatio: 1,
+		samplingRules: []SamplingRule{
+			{Operation: "GET /health*", Ratio: 0},
+			{Operation: "Ping", Ratio: 0},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		decision trace_sdk.SamplingDecision
+	}{
+		{"GET /health", trace_sdk.Drop},
+		{"GET /healthz", trace_sdk.Drop},
+		{"Ping", trace_sdk.Drop},
+		{"PingStream", trace_sdk.RecordAndSample},
+		{"POST /hello/:name", trace_sdk.RecordAndSample},
+	}
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	for _, tt := range tests {
+		res := sampler.ShouldSample(trace_sdk.SamplingParameters{
+			ParentContext: context.Background(),
+			TraceID:       traceID,
+			Name:          tt.name,
+		})
+
+		if res.Decision != tt.decision {
+			t.Errorf("%s: got decision %v, want %v", tt.name, res.Decision, tt.decision)
+		}
+	}
+}
+
+func TestRuleSamplerRespectsParent(t *testing.T) {
+	sampler := buildSampler(&config{
+		sampleRatio:   0,
+		samplingRules: []SamplingRule{{Operation: "*", Ratio: 0}},
+	})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	parent := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+
+	res := sampler.ShouldSample(trace_sdk.SamplingParameters{
+		ParentContext: parent,
+		TraceID:       traceID,
+		Name:          "Ping",
+	})
+
+	if res.Decision != trace_sdk.RecordAndSample {
+		t.Errorf("got decision %v, want %v", res.Decision, trace_sdk.RecordAndSample)
+	}
+}
@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	messageEventName = "message"
+
+	messageTypeSent     = "SENT"
+	messageTypeReceived = "RECEIVED"
+)
+
+var (
+	_ grpc.ServerStream = (*serverStream)(nil)
+	_ grpc.ClientStream = (*clientStream)(nil)
+)
+
+// addMessageEvent records a single stream message on the span, following the
+// otel rpc semantic conventions for message events.
+func addMessageEvent(span trace.Span, messageType string, id int64, msg any) {
+	attrs := []attribute.KeyValue{
+		attribute.String("message.type", messageType),
+		attribute.Int64("message.id", id),
+	}
+
+	if pm, ok := msg.(proto.Message); ok {
+		attrs = append(attrs, attribute.Int("message.uncompressed_size", proto.Size(pm)))
+	}
+
+	span.AddEvent(messageEventName, trace.WithAttributes(attrs...))
+}
+
+func recordStatus(span trace.Span, err error) {
+	s, _ := status.FromError(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(s.Code())))
+	if err != nil {
+		span.SetStatus(codes.Error, s.Message())
+		span.RecordError(err)
+	}
+}
+
+// serverStream wraps grpc.ServerStream so handlers observe a context carrying
+// the span, log_id and trace_id, and every message is recorded on the span.
+type serverStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	span trace.Span
+
+	sentID     atomic.Int64
+	receivedID atomic.Int64
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		addMessageEvent(s.span, messageTypeSent, s.sentID.Add(1), m)
+	}
+
+	return err
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		addMessageEvent(s.span, messageTypeReceived, s.receivedID.Add(1), m)
+	}
+
+	return err
+}
+
+// clientStream wraps grpc.ClientStream and ends the span once the stream is
+// finished, following the rules grpc.ClientConn.NewStream gives to release a
+// stream: RecvMsg returns the reply of a non-server-streaming call or an
+// error, SendMsg, Header or CloseSend fail, or ctx is done. Callers that stop
+// reading a server stream early must cancel ctx, otherwise the span is never
+// ended.
+type clientStream struct {
+	grpc.ClientStream
+	desc *grpc.StreamDesc
+	span trace.Span
+
+	once       sync.Once
+	stop       func() bool
+	sentID     atomic.Int64
+	receivedID atomic.Int64
+}
+
+func newClientStream(ctx context.Context, cs grpc.ClientStream, desc *grpc.StreamDesc, span trace.Span) *clientStream {
+	s := &clientStream{
+		ClientStream: cs,
+		desc:         desc,
+		span:         span,
+	}
+
+	s.stop = context.AfterFunc(ctx, func() {
+		s.end(ctx.Err())
+	})
+
+	return s
+}
+
+func (s *clientStream) finish(err error) {
+	s.stop()
+	s.end(err)
+}
+
+func (s *clientStream) end(err error) {
+	s.once.Do(func() {
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+
+		recordStatus(s.span, err)
+		s.span.End()
+	})
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil {
+		s.finish(err)
+		return err
+	}
+
+	addMessageEvent(s.span, messageTypeSent, s.sentID.Add(1), m)
+	return nil
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.finish(err)
+		return err
+	}
+
+	addMessageEvent(s.span, messageTypeReceived, s.receivedID.Add(1), m)
+	if !s.desc.ServerStreams {
+		// unary response, no further message will arrive
+		s.finish(nil)
+	}
+
+	return nil
+}
+
+func (s *clientStream) Header() (metadata.MD, error) {
+	md, err := s.ClientStream.Header()
+	if err != nil {
+		s.finish(err)
+	}
+
+	return md, err
+}
+
+func (s *clientStream) CloseSend() error {
+	err := s.ClientStream.CloseSend()
+	if err != nil {
+		s.finish(err)
+	}
+
+	return err
+}
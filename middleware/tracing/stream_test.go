@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	trace_sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+)
+
+type fakeClientStream struct {
+	grpc.ClientStream
+
+	replies int
+}
+
+func (s *fakeClientStream) RecvMsg(any) error {
+	if s.replies == 0 {
+		return io.EOF
+	}
+
+	s.replies--
+	return nil
+}
+
+func TestClientStreamEndsSpan(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracer := trace_sdk.NewTracerProvider(trace_sdk.WithSpanProcessor(sr)).Tracer("test")
+
+	// the reply of a client streaming call ends the span, ctx is never canceled
+	_, span := tracer.Start(context.Background(), "Publish")
+	cs := newClientStream(context.Background(), &fakeClientStream{replies: 1}, &grpc.StreamDesc{ClientStreams: true}, span)
+	if err := cs.RecvMsg(nil); err != nil {
+		t.Fatalf("RecvMsg = %v", err)
+	}
+
+	if n := len(sr.Ended()); n != 1 {
+		t.Fatalf("ended spans = %d, want 1", n)
+	}
+
+	// server streams end on io.EOF
+	_, span = tracer.Start(context.Background(), "Watch")
+	cs = newClientStream(context.Background(), &fakeClientStream{replies: 1}, &grpc.StreamDesc{ServerStreams: true}, span)
+	if err := cs.RecvMsg(nil); err != nil {
+		t.Fatalf("RecvMsg = %v", err)
+	}
+
+	if n := len(sr.Ended()); n != 1 {
+		t.Fatalf("ended spans = %d, want 1", n)
+	}
+
+	if err := cs.RecvMsg(nil); !errors.Is(err, io.EOF) {
+		t.Fatalf("RecvMsg = %v, want io.EOF", err)
+	}
+
+	if n := len(sr.Ended()); n != 2 {
+		t.Fatalf("ended spans = %d, want 2", n)
+	}
+
+	// or when ctx is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	_, span = tracer.Start(ctx, "Watch")
+	newClientStream(ctx, &fakeClientStream{replies: 1}, &grpc.StreamDesc{ServerStreams: true}, span)
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for len(sr.Ended()) != 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("span not ended after ctx is canceled")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
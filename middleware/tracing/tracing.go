@@ -6,7 +6,6 @@
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
@@ -14,17 +13,23 @@
 	trace_sdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
 )
 
+// ShutdownFunc flushes pending spans and releases the exporter.
+type ShutdownFunc func(context.Context) error
+
 func InitializeWithCustomTracer(tp trace.TracerProvider, propagator propagation.TextMapPropagator) {
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagator)
 }
 
-func Initialize(ctx context.Context, opts ...Option) (trace.TracerProvider, error) {
+func Initialize(ctx context.Context, opts ...Option) (trace.TracerProvider, ShutdownFunc, error) {
 	conf := &config{
 		endpointType: EndpointType_GRPC,
 		insecure:     true,
+		compression:  Compression_None,
+		sampleRatio:  1,
 	}
 
 	for _, opt := range opts {
@@ -32,25 +37,9 @@ func Initialize(ctx context.Context, opts ...Option) (trace.TracerProvider, erro
 	}
 
 	// create the exporter
-	var err error
-	var exporter *otlptrace.Exporter
-	switch conf.endpointType {
-	case EndpointType_GRPC:
-		exporter, err = otlptracegrpc.New(ctx,
-			otlptracegrpc.WithEndpoint(conf.endpoint),
-			otlptracegrpc.WithInsecure(),
-		)
-	case EndpointType_HTTP:
-		exporter, err = otlptracehttp.New(ctx,
-			otlptracehttp.WithEndpoint(conf.endpoint),
-			otlptracehttp.WithInsecure(),
-		)
-	default:
-		return nil, fmt.Errorf("invalid endpoint type: %s", conf.endpointType)
-	}
-
+	exporter, err := newExporter(ctx, conf)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		return nil, nil, fmt.Errorf("failed to create %s exporter: %w", conf.endpointType, err)
 	}
 
 	// create resource
@@ -64,17 +53,19 @@ func Initialize(ctx context.Context, opts ...Option) (trace.TracerProvider, erro
 
 	res, err := resource.New(ctx, resource.WithAttributes(metaAttributes...))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+		return nil, nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
-	// create sampler
-	sampler := trace_sdk.AlwaysSample()
-
 	// create tracer provider
+	processor := trace_sdk.WithBatcher(exporter, batchOptions(conf)...)
+	if isLocalEndpointType(conf.endpointType) {
+		processor = trace_sdk.WithSyncer(exporter)
+	}
+
 	tp := trace_sdk.NewTracerProvider(
-		trace_sdk.WithBatcher(exporter),
+		processor,
 		trace_sdk.WithResource(res),
-		trace_sdk.WithSampler(sampler),
+		trace_sdk.WithSampler(buildSampler(conf)),
 	)
 
 	propagator := propagation.NewCompositeTextMapPropagator(
@@ -83,5 +74,72 @@ func Initialize(ctx context.Context, opts ...Option) (trace.TracerProvider, erro
 	)
 
 	InitializeWithCustomTracer(tp, propagator)
-	return tp, nil
+	return tp, tp.Shutdown, nil
+}
+
+func grpcExporterOptions(conf *config) []otlptracegrpc.Option {
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(conf.endpoint),
+	}
+
+	if conf.insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	} else if conf.tlsConf != nil {
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(conf.tlsConf)))
+	}
+
+	if len(conf.headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(conf.headers))
+	}
+
+	if conf.compression == Compression_Gzip {
+		opts = append(opts, otlptracegrpc.WithCompressor(string(Compression_Gzip)))
+	}
+
+	return opts
+}
+
+func httpExporterOptions(conf *config) []otlptracehttp.Option {
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(conf.endpoint),
+	}
+
+	if conf.insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	} else if conf.tlsConf != nil {
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(conf.tlsConf))
+	}
+
+	if len(conf.headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(conf.headers))
+	}
+
+	if conf.compression == Compression_Gzip {
+		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	} else {
+		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.NoCompression))
+	}
+
+	return opts
+}
+
+func batchOptions(conf *config) []trace_sdk.BatchSpanProcessorOption {
+	opts := make([]trace_sdk.BatchSpanProcessorOption, 0, 4)
+	if conf.batchTimeout > 0 {
+		opts = append(opts, trace_sdk.WithBatchTimeout(conf.batchTimeout))
+	}
+
+	if conf.exportTimeout > 0 {
+		opts = append(opts, trace_sdk.WithExportTimeout(conf.exportTimeout))
+	}
+
+	if conf.maxQueueSize > 0 {
+		opts = append(opts, trace_sdk.WithMaxQueueSize(conf.maxQueueSize))
+	}
+
+	if conf.maxExportBatchSize > 0 {
+		opts = append(opts, trace_sdk.WithMaxExportBatchSize(conf.maxExportBatchSize))
+	}
+
+	return opts
 }
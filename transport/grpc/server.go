@@ -131,24 +131,14 @@ func (s *Server) Start(ctx context.Context) error {
 		return s.err
 	}
 
-	s.baseCtx = ctx
 	log.Infof("[gRPC] server listening on %s", s.lis.Addr().String())
-
-	if !s.customHealth {
-		s.health.Resume()
-	}
+	s.Serving(ctx)
 
 	return s.Serve(s.lis)
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	if s.cleanup != nil {
-		s.cleanup()
-	}
-
-	if !s.customHealth {
-		s.health.Shutdown()
-	}
+	s.NotServing()
 
 	done := make(chan struct{})
 	go func() {
@@ -176,3 +166,24 @@ func (s *Server) Endpoint() (*url.URL, error) {
 }
 
 // endregion
+
+// Serving marks the server as serving without starting it, requests are passed
+// in by ServeHTTP from an http server that shares its port.
+func (s *Server) Serving(ctx context.Context) {
+	s.baseCtx = ctx
+	if !s.customHealth {
+		s.health.Resume()
+	}
+}
+
+// NotServing marks the server as not serving and releases the admin services,
+// it doesn't stop the server.
+func (s *Server) NotServing() {
+	if s.cleanup != nil {
+		s.cleanup()
+	}
+
+	if !s.customHealth {
+		s.health.Shutdown()
+	}
+}
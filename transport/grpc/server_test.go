@@ -4,6 +4,7 @@
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -12,7 +13,10 @@
 	"github.com/dizzrt/ellie/internal/mock/ping"
 	"github.com/dizzrt/ellie/log"
 	"github.com/dizzrt/ellie/middleware/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	trace_sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"google.golang.org/grpc"
 )
 
@@ -27,6 +31,24 @@ func (s *pingServer) Ping(ctx context.Context, req *ping.PingRequest) (*ping.Pin
 	}, nil
 }
 
+func (s *pingServer) PingStream(stream ping.PingService_PingStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		log.CtxInfof(stream.Context(), "ping stream request: %v", req)
+		if err := stream.Send(&ping.PingResponse{Message: "pong"}); err != nil {
+			return err
+		}
+	}
+}
+
 type testKey struct{}
 
 func getPingServer(t *testing.T, opts ...ServerOption) *Server {
@@ -175,7 +197,7 @@ func TestPingWithTracing(t *testing.T) {
 	ctx := context.Background()
 
 	// init tracing provider
-	tp, err := tracing.Initialize(ctx,
+	_, shutdown, err := tracing.Initialize(ctx,
 		tracing.ServiceName("transport-test"),
 		tracing.ServiceVersion("v0.0.1-dev"),
 		tracing.Endpoint("localhost:4317"),
@@ -194,9 +216,7 @@ func TestPingWithTracing(t *testing.T) {
 		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 		defer cancel()
 
-		if sdkTp, ok := tp.(*trace_sdk.TracerProvider); ok {
-			sdkTp.Shutdown(ctx)
-		}
+		_ = shutdown(ctx)
 	}()
 
 	// start server
@@ -239,3 +259,93 @@ func TestPingWithTracing(t *testing.T) {
 	t.Log(resp)
 	_ = srv.Stop(ctx)
 }
+
+func TestPingStreamWithTracing(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := trace_sdk.NewTracerProvider(trace_sdk.WithSpanProcessor(recorder))
+	prevTp := otel.GetTracerProvider()
+	prevPropagator := otel.GetTextMapPropagator()
+	tracing.InitializeWithCustomTracer(tp, propagation.TraceContext{})
+	defer tracing.InitializeWithCustomTracer(prevTp, prevPropagator)
+
+	srv := getPingServer(t, StreamInterceptor(tracing.StreamServerInterceptor()))
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+
+	time.Sleep(time.Second)
+
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := DialInsecure(
+		WithEndpoint(e.Host),
+		WithStreamClientInterceptor(
+			tracing.StreamClientInterceptor(),
+		),
+	)
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := ping.NewPingServiceClient(conn)
+	stream, err := client.PingStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 3 {
+		if err := stream.Send(&ping.PingRequest{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := stream.Recv(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	_ = srv.Stop(ctx)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 ended spans, got %d", len(spans))
+	}
+
+	for _, span := range spans {
+		if span.Name() != "PingStream" {
+			t.Errorf("unexpected span name: %s", span.Name())
+		}
+
+		// 3 sent and 3 received messages on both sides
+		if len(span.Events()) != 6 {
+			t.Errorf("span %s: expected 6 message events, got %d", span.SpanKind(), len(span.Events()))
+		}
+	}
+
+	if spans[0].SpanKind() == spans[1].SpanKind() {
+		t.Errorf("expected a server and a client span, got %s and %s", spans[0].SpanKind(), spans[1].SpanKind())
+	}
+
+	if spans[0].SpanContext().TraceID() != spans[1].SpanContext().TraceID() {
+		t.Error("expected client and server spans to share a trace id")
+	}
+}
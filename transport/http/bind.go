@@ -1,17 +1,193 @@
 package http
 
 import (
+	"fmt"
 	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/dizzrt/ellie/encoding"
 	"github.com/dizzrt/ellie/encoding/form"
+	"github.com/dizzrt/ellie/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+var pathVarPattern = regexp.MustCompile(`\{\s*([^{}=\s]+)\s*(=[^{}]*)?\}`)
+
+// PathVar is a variable of a google.api.http path template, Pattern is the
+// segment pattern of {name=shelves/*} and empty for {name} and {name=*}.
+type PathVar struct {
+	Name    string
+	Pattern string
+}
+
+// BindQueryParams decodes vars into target with the form codec, failures are
+// reported by InvalidArgumentError.
 func BindQueryParams(vars url.Values, target any) error {
 	if err := encoding.GetCodec(form.Name).Unmarshal([]byte(vars.Encode()), target); err != nil {
-		// TODO wrap errors
-		return err
+		return InvalidArgumentError(err)
 	}
 
 	return nil
 }
+
+// InvalidArgumentError wraps a binding failure into an InvalidArgument
+// *errors.StandardError, the field of a *form.FieldError is kept in the
+// metadata. Standard errors are returned as is.
+func InvalidArgumentError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*errors.StandardError); ok {
+		return err
+	}
+
+	status := codes.InvalidArgument
+	se := errors.NewStandardError(&status, int(codes.InvalidArgument), "INVALID_ARGUMENT", err.Error())
+	if fe, ok := err.(*form.FieldError); ok {
+		return se.WithMetadata(map[string]string{"field": fe.Field}).WithCause(err)
+	}
+
+	return se.WithCause(err)
+}
+
+// EncodeURL expands the google.api.http path template with the fields of msg
+// and encodes the fields bound neither to the path nor to body as query
+// parameters, body follows the semantics of ginx.Body. Variables with a
+// pattern, e.g. {name=shelves/*}, keep the slashes of their value. An unset
+// path field is reported as an InvalidArgument error.
+func EncodeURL(template string, msg proto.Message, body string) (string, error) {
+	values, err := form.EncodeValues(msg)
+	if err != nil {
+		return "", err
+	}
+
+	var missing error
+	desc := msg.ProtoReflect().Descriptor()
+	path := pathVarPattern.ReplaceAllStringFunc(template, func(v string) string {
+		m := pathVarPattern.FindStringSubmatch(v)
+		key := jsonFieldPath(desc, m[1])
+		value := values.Get(key)
+		deleteValues(values, key)
+		if value == "" && missing == nil {
+			missing = &form.FieldError{Field: m[1], Err: fmt.Errorf("path variables can't be empty")}
+		}
+
+		if m[2] == "" {
+			return url.PathEscape(value)
+		}
+
+		segments := strings.Split(value, "/")
+		for i, seg := range segments {
+			segments[i] = url.PathEscape(seg)
+		}
+
+		return strings.Join(segments, "/")
+	})
+
+	if missing != nil {
+		return "", InvalidArgumentError(missing)
+	}
+
+	switch body {
+	case "":
+	case "*":
+		return path, nil
+	default:
+		deleteValues(values, jsonFieldPath(desc, body))
+	}
+
+	if len(values) == 0 {
+		return path, nil
+	}
+
+	return path + "?" + values.Encode(), nil
+}
+
+// RoutePath converts a google.api.http path template into a router path like
+// generated code does, {name} becomes :name and the wildcards of
+// {name=shelves/*} are matched as name.0, name.1..., see ginx.PathVar. The
+// variables of the template are returned in order.
+func RoutePath(template string) (string, []PathVar, error) {
+	var vars []PathVar
+	path := pathVarPattern.ReplaceAllStringFunc(template, func(v string) string {
+		m := pathVarPattern.FindStringSubmatch(v)
+		name, pattern := m[1], strings.TrimSpace(strings.TrimPrefix(m[2], "="))
+		if pattern == "" || pattern == "*" {
+			vars = append(vars, PathVar{Name: name})
+			return ":" + name
+		}
+
+		segments := strings.Split(pattern, "/")
+		n := 0
+		for i, seg := range segments {
+			switch seg {
+			case "*":
+				segments[i] = ":" + name + "." + strconv.Itoa(n)
+				n++
+			case "**":
+				segments[i] = "*" + name + "." + strconv.Itoa(n)
+				n++
+			}
+		}
+
+		vars = append(vars, PathVar{Name: name, Pattern: pattern})
+		return strings.Join(segments, "/")
+	})
+
+	if i := strings.Index(path, "/*"); i >= 0 && strings.Contains(path[i+1:], "/") {
+		return "", nil, fmt.Errorf("** must match the last segments of %s", template)
+	}
+
+	return path, vars, nil
+}
+
+// SimplifyPath reduces the variables of a google.api.http path template to
+// {name}, the form of OpenAPI paths, and returns them in order like RoutePath.
+func SimplifyPath(template string) (string, []PathVar) {
+	var vars []PathVar
+	path := pathVarPattern.ReplaceAllStringFunc(template, func(v string) string {
+		m := pathVarPattern.FindStringSubmatch(v)
+		name, pattern := m[1], strings.TrimSpace(strings.TrimPrefix(m[2], "="))
+		if pattern == "*" {
+			pattern = ""
+		}
+
+		vars = append(vars, PathVar{Name: name, Pattern: pattern})
+		return "{" + name + "}"
+	})
+
+	return path, vars
+}
+
+// jsonFieldPath converts a dotted path of proto field names into the json
+// names used by form.EncodeValues.
+func jsonFieldPath(desc protoreflect.MessageDescriptor, path string) string {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		if desc == nil {
+			break
+		}
+
+		fd := desc.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			break
+		}
+
+		names[i], desc = fd.JSONName(), fd.Message()
+	}
+
+	return strings.Join(names, ".")
+}
+
+func deleteValues(values url.Values, key string) {
+	for k := range values {
+		if k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(k, key+"[") {
+			delete(values, k)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// bodyHandler decodes compressed request bodies and limits their size before
+// the request reaches the engine, the limit applies to the decoded body.
+func (s *Server) bodyHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.decompressRequestBody {
+			if encodings := contentEncodings(r.Header); len(encodings) > 0 {
+				body, err := decodeBody(r.Body, encodings)
+				if err != nil {
+					s.WriteResponse(w, r, nil, err)
+					return
+				}
+
+				r = r.Clone(r.Context())
+				r.Body = body
+				r.ContentLength = -1
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+			}
+		}
+
+		if s.maxRequestBodySize > 0 {
+			if r.ContentLength > s.maxRequestBodySize {
+				s.WriteResponse(w, r, nil, ErrRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, s.maxRequestBodySize)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func contentEncodings(h http.Header) []string {
+	var encodings []string
+	for _, v := range h.Values("Content-Encoding") {
+		for _, e := range strings.Split(v, ",") {
+			if e = strings.ToLower(strings.TrimSpace(e)); e != "" && e != "identity" {
+				encodings = append(encodings, e)
+			}
+		}
+	}
+
+	return encodings
+}
+
+// decodeBody undoes the content encodings of body, they are listed in the
+// order they were applied.
+func decodeBody(body io.ReadCloser, encodings []string) (io.ReadCloser, error) {
+	closers := []io.Closer{body}
+	var r io.Reader = body
+	for _, e := range slices.Backward(encodings) {
+		var err error
+		switch e {
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(r)
+		case "deflate":
+			// http deflate is the zlib format
+			r, err = zlib.NewReader(r)
+		case "zstd":
+			var d *zstd.Decoder
+			if d, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1)); err == nil {
+				r = d
+				closers = append(closers, closerFunc(func() error {
+					d.Close()
+					return nil
+				}))
+			}
+		default:
+			err = ErrUnsupportedMediaType
+		}
+
+		if err != nil {
+			if err != ErrUnsupportedMediaType {
+				err = InvalidArgumentError(err)
+			}
+
+			return nil, err
+		}
+	}
+
+	return &decodedBody{Reader: r, closers: closers}, nil
+}
+
+type decodedBody struct {
+	io.Reader
+
+	closers []io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	var err error
+	for _, c := range slices.Backward(b.closers) {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
@@ -0,0 +1,75 @@
+package http_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/dizzrt/ellie/transport/http/ginx"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestBody(t *testing.T) {
+	srv := http.NewServer(http.MaxRequestBodySize(64), http.DecompressRequestBody(true))
+	srv.Engine().POST("/echo", func(ctx *gin.Context) {
+		var req struct {
+			Name string `json:"name"`
+		}
+
+		err := ginx.DecodeRequest(ctx, &req)
+		srv.EncodeResponse(ctx, req.Name, err)
+	})
+
+	t.Run("decompression", func(t *testing.T) {
+		var gz bytes.Buffer
+		gw := gzip.NewWriter(&gz)
+		_, _ = gw.Write([]byte(`{"name":"gzip"}`))
+		_ = gw.Close()
+		w := serve(srv, nhttp.MethodPost, "/echo", &gz, "Content-Type", "application/json", "Content-Encoding", "gzip")
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"data":"gzip"`)
+
+		var zs bytes.Buffer
+		zw, _ := zstd.NewWriter(&zs)
+		_, _ = zw.Write([]byte(`{"name":"zstd"}`))
+		_ = zw.Close()
+		w = serve(srv, nhttp.MethodPost, "/echo", &zs, "Content-Type", "application/json", "Content-Encoding", "zstd")
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"data":"zstd"`)
+
+		w = serve(srv, nhttp.MethodPost, "/echo", strings.NewReader(`{"name":"br"}`), "Content-Type", "application/json", "Content-Encoding", "br")
+		assert.Equal(t, nhttp.StatusUnsupportedMediaType, w.Code)
+		assert.Contains(t, w.Body.String(), "unsupported media type")
+
+		w = serve(srv, nhttp.MethodPost, "/echo", strings.NewReader(`not gzip`), "Content-Type", "application/json", "Content-Encoding", "gzip")
+		assert.Equal(t, nhttp.StatusBadRequest, w.Code)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		large := `{"name":"` + strings.Repeat("a", 100) + `"}`
+
+		// rejected by the content length before the handler runs
+		w := serve(srv, nhttp.MethodPost, "/echo", strings.NewReader(large), "Content-Type", "application/json")
+		assert.Equal(t, nhttp.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "request entity too large")
+
+		// bodies of unknown length are limited while reading, after decoding
+		var gz bytes.Buffer
+		gw := gzip.NewWriter(&gz)
+		_, _ = gw.Write([]byte(large))
+		_ = gw.Close()
+		assert.Less(t, gz.Len(), 64)
+		w = serve(srv, nhttp.MethodPost, "/echo", io.MultiReader(&gz), "Content-Type", "application/json", "Content-Encoding", "gzip")
+		assert.Equal(t, nhttp.StatusRequestEntityTooLarge, w.Code)
+
+		w = serve(srv, nhttp.MethodPost, "/echo", strings.NewReader(`{"name":"ok"}`), "Content-Type", "application/json")
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+	})
+}
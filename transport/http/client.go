@@ -0,0 +1,479 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/dizzrt/ellie/encoding"
+	"github.com/dizzrt/ellie/encoding/form"
+	"github.com/dizzrt/ellie/encoding/json"
+	eproto "github.com/dizzrt/ellie/encoding/proto"
+	exml "github.com/dizzrt/ellie/encoding/xml"
+	"github.com/dizzrt/ellie/errors"
+	"github.com/dizzrt/ellie/internal/endpoint"
+	"github.com/dizzrt/ellie/log"
+	"github.com/dizzrt/ellie/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/codes"
+)
+
+const defaultContentType = "application/json"
+
+type Client struct {
+	opts     clientOptions
+	target   *url.URL
+	resolver *resolver
+	cc       *http.Client
+}
+
+type CallOption func(*callInfo)
+
+type callInfo struct {
+	contentType string
+	header      http.Header
+}
+
+// CallContentType overrides the client content type for a single call.
+func CallContentType(contentType string) CallOption {
+	return func(c *callInfo) {
+		c.contentType = contentType
+	}
+}
+
+func CallHeader(key, value string) CallOption {
+	return func(c *callInfo) {
+		c.header.Add(key, value)
+	}
+}
+
+// envelope is the response body written by Server.WrapHTTPResponse, or by
+// Response for other codecs, see decodeEnvelope.
+type envelope struct {
+	Data     sonic.NoCopyRawMessage `json:"data"`
+	Status   int32                  `json:"status"`
+	Message  string                 `json:"message"`
+	Reason   string                 `json:"reason,omitempty"`
+	Metadata map[string]string      `json:"metadata,omitempty"`
+}
+
+func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
+	options := clientOptions{
+		timeout:                2000 * time.Millisecond,
+		contentType:            defaultContentType,
+		userAgent:              "ellie-http-client",
+		printDiscoveryDebugLog: true,
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	transport := options.transport
+	if transport == nil {
+		temp := http.DefaultTransport.(*http.Transport).Clone()
+		temp.TLSClientConfig = options.tlsConf
+		transport = temp
+	}
+
+	c := &Client{
+		opts: options,
+		cc:   &http.Client{Transport: transport},
+	}
+
+	target, err := parseTarget(options.endpoint, options.tlsConf != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Scheme == discoveryScheme {
+		if options.discovery == nil {
+			return nil, fmt.Errorf("discovery is required for endpoint %s", options.endpoint)
+		}
+
+		c.resolver, err = newResolver(ctx, options.discovery, target.Path, options.tlsConf == nil, options.timeout, options.printDiscoveryDebugLog)
+		if err != nil {
+			return nil, err
+		}
+
+		target = &url.URL{Scheme: endpoint.Scheme("http", options.tlsConf != nil)}
+	}
+
+	c.target = target
+	return c, nil
+}
+
+func parseTarget(ept string, isSecure bool) (*url.URL, error) {
+	if ept == "" {
+		return nil, fmt.Errorf("endpoint is empty")
+	}
+
+	if !strings.Contains(ept, "://") {
+		ept = endpoint.Scheme("http", isSecure) + "://" + ept
+	}
+
+	return url.Parse(ept)
+}
+
+// Invoke sends args to path and decodes the response data into reply, error
+// envelopes are decoded into *errors.StandardError.
+func (c *Client) Invoke(ctx context.Context, method, path string, args, reply any, opts ...CallOption) error {
+	info := &callInfo{
+		contentType: c.opts.contentType,
+		header:      make(http.Header),
+	}
+
+	for _, opt := range opts {
+		opt(info)
+	}
+
+	h := func(ctx context.Context, req any) (any, error) {
+		res, err := c.invoke(ctx, method, path, req, reply, info)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+
+	if len(c.opts.middleware) > 0 {
+		h = middleware.Chain(c.opts.middleware...)(h)
+	}
+
+	_, err := h(ctx, args)
+	return err
+}
+
+func (c *Client) invoke(ctx context.Context, method, path string, args, reply any, info *callInfo) (any, error) {
+	var body io.Reader
+	if args != nil {
+		data, err := codecForContentType(info.contentType).Marshal(args)
+		if err != nil {
+			return nil, err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	if c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, vs := range info.header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", info.contentType)
+	}
+
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", info.contentType)
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := c.decodeResponse(res, reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// Do sends a raw request, requests without a host are sent to the client
+// endpoint or to a discovered instance.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == "" {
+		host := c.target.Host
+		if c.resolver != nil {
+			var err error
+			if host, err = c.resolver.pick(); err != nil {
+				return nil, err
+			}
+		}
+
+		req.URL.Scheme = c.target.Scheme
+		req.URL.Host = host
+		if c.target.Path != "" && c.target.Path != "/" {
+			req.URL.Path = strings.TrimSuffix(c.target.Path, "/") + req.URL.Path
+		}
+	}
+
+	if c.opts.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.opts.userAgent)
+	}
+
+	ctx := req.Context()
+	logID := log.LogIDFromContext(ctx)
+	if logID != "" && req.Header.Get("X-Log-ID") == "" {
+		req.Header.Set("X-Log-ID", logID)
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return c.cc.Do(req)
+}
+
+func (c *Client) Close() error {
+	if c.resolver != nil {
+		c.resolver.Close()
+	}
+
+	c.cc.CloseIdleConnections()
+	return nil
+}
+
+func (c *Client) decodeResponse(res *http.Response, reply any) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	isOK := res.StatusCode >= 200 && res.StatusCode < 300
+	if mt, _, _ := mime.ParseMediaType(contentType); mt == ProblemContentType && !isOK {
+		return errorFromProblem(res.StatusCode, data)
+	}
+
+	if len(data) == 0 {
+		if !isOK {
+			return errorFromResponse(res.StatusCode, nil, data)
+		}
+
+		return nil
+	}
+
+	// protobuf responses carry the message or an errors.ErrorCore, every
+	// other codec wraps them in an envelope
+	codec := codecForContentType(contentType)
+	if codec.Name() == eproto.Name {
+		if !isOK {
+			core := &errors.ErrorCore{}
+			if err := codec.Unmarshal(data, core); err == nil {
+				return errorFromCore(res.StatusCode, core)
+			}
+
+			return errorFromResponse(res.StatusCode, nil, data)
+		}
+
+		if reply == nil {
+			return nil
+		}
+
+		return codec.Unmarshal(data, reply)
+	}
+
+	env, err := decodeEnvelope(codec, data)
+	if err != nil {
+		if !isOK {
+			return errorFromResponse(res.StatusCode, nil, data)
+		}
+
+		return err
+	}
+
+	if !isOK || int(env.Status) != c.opts.successCode {
+		return errorFromResponse(res.StatusCode, env, data)
+	}
+
+	if reply == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+
+	return codec.Unmarshal(env.Data, reply)
+}
+
+// decodeEnvelope decodes the envelope rendered by the server for codec, the
+// data is kept in the codec format.
+func decodeEnvelope(codec encoding.Codec, data []byte) (*envelope, error) {
+	switch codec.Name() {
+	case json.Name:
+		env := &envelope{}
+		if err := sonic.Unmarshal(data, env); err != nil {
+			return nil, err
+		}
+
+		return env, nil
+	case form.Name:
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return nil, err
+		}
+
+		status, err := strconv.ParseInt(values.Get("status"), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		env := &envelope{Status: int32(status), Message: values.Get("message"), Reason: values.Get("reason")}
+		fields := url.Values{}
+		for key, v := range values {
+			if name, ok := strings.CutPrefix(key, "data."); ok {
+				fields[name] = v
+			} else if name, ok := strings.CutPrefix(key, "metadata["); ok && strings.HasSuffix(name, "]") {
+				if env.Metadata == nil {
+					env.Metadata = make(map[string]string)
+				}
+
+				env.Metadata[strings.TrimSuffix(name, "]")] = v[0]
+			}
+		}
+
+		env.Data = []byte(fields.Encode())
+		return env, nil
+	case exml.Name:
+		// the data element is decoded by the reply type
+		var res struct {
+			Data struct {
+				Inner []byte `xml:",innerxml"`
+			} `xml:"data"`
+			Status   int32            `xml:"status"`
+			Message  string           `xml:"message"`
+			Reason   string           `xml:"reason"`
+			Metadata ResponseMetadata `xml:"metadata"`
+		}
+
+		if err := codec.Unmarshal(data, &res); err != nil {
+			return nil, err
+		}
+
+		env := &envelope{Status: res.Status, Message: res.Message, Reason: res.Reason, Metadata: res.Metadata}
+		if len(bytes.TrimSpace(res.Data.Inner)) > 0 {
+			env.Data = slices.Concat([]byte("<data>"), res.Data.Inner, []byte("</data>"))
+		}
+
+		return env, nil
+	default:
+		res := &Response{}
+		if err := codec.Unmarshal(data, res); err != nil {
+			return nil, err
+		}
+
+		env := &envelope{Status: int32(res.Status), Message: res.Message, Reason: res.Reason, Metadata: res.Metadata}
+		if res.Data != nil {
+			var err error
+			if env.Data, err = codec.Marshal(res.Data); err != nil {
+				return nil, err
+			}
+		}
+
+		return env, nil
+	}
+}
+
+func errorFromResponse(httpCode int, env *envelope, body []byte) error {
+	status := codes.Unknown
+	if httpCode >= 300 {
+		status = GRPCCodeFromHTTPStatus(httpCode)
+	}
+
+	if env == nil {
+		message := strings.TrimSpace(string(body))
+		if message == "" {
+			message = http.StatusText(httpCode)
+		}
+
+		return errors.NewStandardError(&status, httpCode, "HTTP_"+strconv.Itoa(httpCode), message)
+	}
+
+	reason := env.Reason
+	if reason == "" {
+		reason = "HTTP_" + strconv.Itoa(httpCode)
+	}
+
+	se := errors.NewStandardError(&status, int(env.Status), reason, env.Message)
+	if len(env.Metadata) > 0 {
+		return se.WithMetadata(env.Metadata)
+	}
+
+	return se
+}
+
+func errorFromCore(httpCode int, core *errors.ErrorCore) error {
+	status := GRPCCodeFromHTTPStatus(httpCode)
+	if core.Status != nil {
+		status = codes.Code(core.GetStatus())
+	}
+
+	se := errors.NewStandardError(&status, int(core.GetCode()), core.GetReason(), core.GetMessage())
+	if len(core.GetMetadata()) > 0 {
+		return se.WithMetadata(core.GetMetadata())
+	}
+
+	return se
+}
+
+// errorFromProblem decodes a problem document written by
+// ProblemResponseEncoder, string extension members are kept as metadata.
+func errorFromProblem(httpCode int, body []byte) error {
+	doc := make(map[string]any)
+	if err := sonic.Unmarshal(body, &doc); err != nil {
+		return errorFromResponse(httpCode, nil, body)
+	}
+
+	code := httpCode
+	if v, ok := doc["code"].(float64); ok {
+		code = int(v)
+	}
+
+	reason, _ := doc["reason"].(string)
+	if reason == "" {
+		reason = "HTTP_" + strconv.Itoa(httpCode)
+	}
+
+	message, _ := doc["detail"].(string)
+	if message == "" {
+		message, _ = doc["title"].(string)
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range doc {
+		if _, ok := problemMembers[k]; ok {
+			continue
+		}
+
+		if str, ok := v.(string); ok {
+			metadata[k] = str
+		}
+	}
+
+	status := GRPCCodeFromHTTPStatus(httpCode)
+	se := errors.NewStandardError(&status, code, reason, message)
+	if len(metadata) > 0 {
+		return se.WithMetadata(metadata)
+	}
+
+	return se
+}
+
+// codecForContentType returns the codec registered for contentType, json is
+// used when no codec is registered.
+func codecForContentType(contentType string) encoding.Codec {
+	if codec := encoding.GetCodecByContentType(contentType); codec != nil {
+		return codec
+	}
+
+	return encoding.GetCodec(json.Name)
+}
@@ -0,0 +1,235 @@
+package http_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/errors"
+	"github.com/dizzrt/ellie/internal/mock/ping"
+	"github.com/dizzrt/ellie/registry"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+type staticDiscovery struct {
+	instances []*registry.ServiceInstance
+}
+
+type staticWatcher struct {
+	ctx       context.Context
+	instances []*registry.ServiceInstance
+	sent      bool
+}
+
+func (d *staticDiscovery) GetService(_ context.Context, _ string) ([]*registry.ServiceInstance, error) {
+	return d.instances, nil
+}
+
+func (d *staticDiscovery) Watch(ctx context.Context, _ string) (registry.Watcher, error) {
+	return &staticWatcher{ctx: ctx, instances: d.instances}, nil
+}
+
+func (w *staticWatcher) Next() ([]*registry.ServiceInstance, error) {
+	if !w.sent {
+		w.sent = true
+		return w.instances, nil
+	}
+
+	<-w.ctx.Done()
+	return nil, w.ctx.Err()
+}
+
+func (w *staticWatcher) Stop() error {
+	return nil
+}
+
+func startPingServer(t *testing.T, opts ...http.ServerOption) *http.Server {
+	srv := http.NewServer(opts...)
+	ping.RegisterPingServiceHTTPServer(srv, &pingServer{})
+	srv.Engine().GET("/error", func(ctx *gin.Context) {
+		scode := codes.NotFound
+		err := errors.NewStandardError(&scode, 40401, "USER_NOT_FOUND", "user not found").WithMetadata(map[string]string{"id": "42"})
+		srv.EncodeResponse(ctx, nil, err)
+	})
+
+	go func() {
+		if err := srv.Start(context.Background()); err != nil {
+			panic(err)
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	t.Cleanup(func() {
+		_ = srv.Stop(context.Background())
+	})
+
+	return srv
+}
+
+func TestClientInvoke(t *testing.T) {
+	ctx := context.Background()
+	srv := startPingServer(t, http.DefaultSuccessCode(10000))
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+
+	client, err := http.NewClient(ctx,
+		http.WithEndpoint(e.Host),
+		http.WithSuccessCode(10000),
+	)
+	assert.NoError(t, err)
+	defer client.Close()
+
+	reply := &ping.HelloResponse{}
+	err = client.Invoke(ctx, nhttp.MethodPost, "/hello/ellie", &ping.HelloRequest{Type: "mock"}, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello ellie, type is mock", reply.GetMessage())
+
+	err = client.Invoke(ctx, nhttp.MethodGet, "/error", nil, nil)
+	se, ok := err.(*errors.StandardError)
+	if assert.True(t, ok, "expected *errors.StandardError, got %T", err) {
+		assert.Equal(t, int32(40401), se.Code())
+		assert.Equal(t, "USER_NOT_FOUND", se.Reason())
+		assert.Equal(t, "user not found", se.Message())
+		assert.Equal(t, codes.NotFound, *se.Status())
+		assert.Equal(t, map[string]string{"id": "42"}, se.Metadata())
+	}
+}
+
+func TestClientCodecs(t *testing.T) {
+	ctx := context.Background()
+	srv := startPingServer(t)
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+
+	contentTypes := []string{
+		"application/json",
+		"application/xml",
+		"application/yaml",
+		"application/toml",
+		"application/x-www-form-urlencoded",
+		"application/x-protobuf",
+	}
+
+	for _, contentType := range contentTypes {
+		t.Run(contentType, func(t *testing.T) {
+			client, err := http.NewClient(ctx, http.WithEndpoint(e.Host), http.WithContentType(contentType))
+			assert.NoError(t, err)
+			defer client.Close()
+
+			reply := &ping.HelloResponse{}
+			err = client.Invoke(ctx, nhttp.MethodPost, "/hello/ellie", &ping.HelloRequest{Type: "codec"}, reply)
+			assert.NoError(t, err)
+			assert.Equal(t, "hello ellie, type is codec", reply.GetMessage())
+
+			err = client.Invoke(ctx, nhttp.MethodGet, "/error", nil, nil)
+			se, ok := err.(*errors.StandardError)
+			if assert.True(t, ok, "expected *errors.StandardError, got %T", err) {
+				assert.Equal(t, int32(40401), se.Code())
+				assert.Equal(t, "USER_NOT_FOUND", se.Reason())
+				assert.Equal(t, "user not found", se.Message())
+				assert.Equal(t, map[string]string{"id": "42"}, se.Metadata())
+			}
+		})
+	}
+
+	// a status other than the success code is an error for every codec
+	client, err := http.NewClient(ctx, http.WithEndpoint(e.Host), http.WithContentType("application/yaml"), http.WithSuccessCode(10000))
+	assert.NoError(t, err)
+	defer client.Close()
+
+	err = client.Invoke(ctx, nhttp.MethodPost, "/hello/ellie", &ping.HelloRequest{}, &ping.HelloResponse{})
+	assert.Error(t, err)
+}
+
+func TestClientDiscovery(t *testing.T) {
+	ctx := context.Background()
+	srv := startPingServer(t)
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+
+	dis := &staticDiscovery{instances: []*registry.ServiceInstance{{
+		ID:        "1",
+		Name:      "ping",
+		Endpoints: []string{"grpc://127.0.0.1:1", e.String()},
+	}}}
+
+	client, err := http.NewClient(ctx,
+		http.WithEndpoint("discovery:///ping"),
+		http.WithDiscovery(dis),
+	)
+	assert.NoError(t, err)
+	defer client.Close()
+
+	reply := &ping.PingResponse{}
+	err = client.Invoke(ctx, nhttp.MethodGet, "/ping", nil, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", reply.GetMessage())
+}
+
+func TestGeneratedClient(t *testing.T) {
+	ctx := context.Background()
+	srv := startPingServer(t)
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+
+	client, err := http.NewClient(ctx, http.WithEndpoint(e.Host))
+	assert.NoError(t, err)
+	defer client.Close()
+
+	pc := ping.NewPingServiceHTTPClient(client)
+	pong, err := pc.Ping(ctx, &ping.PingRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", pong.GetMessage())
+
+	hello, err := pc.Hello(ctx, &ping.HelloRequest{Name: "ellie", Type: "client"})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello ellie, type is client", hello.GetMessage())
+}
+
+func TestEncodeURL(t *testing.T) {
+	req := &ping.HelloRequest{Name: "a/b", Type: "x y"}
+
+	path, err := http.EncodeURL("/hello/{name}", req, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "/hello/a%2Fb?type=x+y", path)
+
+	path, err = http.EncodeURL("/hello/{name=*/*}", req, "*")
+	assert.NoError(t, err)
+	assert.Equal(t, "/hello/a/b", path)
+
+	path, err = http.EncodeURL("/hello", req, "type")
+	assert.NoError(t, err)
+	assert.Equal(t, "/hello?name=a%2Fb", path)
+
+	// an unset path field would produce an empty segment
+	_, err = http.EncodeURL("/hello/{name}/type", &ping.HelloRequest{Type: "x"}, "")
+	se, ok := err.(*errors.StandardError)
+	if assert.True(t, ok, "expected *errors.StandardError, got %T", err) {
+		assert.Equal(t, codes.InvalidArgument, *se.Status())
+		assert.Equal(t, "name", se.Metadata()["field"])
+	}
+}
+
+func TestRoutePath(t *testing.T) {
+	path, vars, err := http.RoutePath("/v1/{parent=shelves/*}/books/{book.id}:move")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/shelves/:parent.0/books/:book.id:move", path)
+	assert.Equal(t, []http.PathVar{{Name: "parent", Pattern: "shelves/*"}, {Name: "book.id"}}, vars)
+
+	path, vars, err = http.RoutePath("/files/{name=**}")
+	assert.NoError(t, err)
+	assert.Equal(t, "/files/*name.0", path)
+	assert.Equal(t, []http.PathVar{{Name: "name", Pattern: "**"}}, vars)
+
+	_, _, err = http.RoutePath("/files/{name=**}/meta")
+	assert.Error(t, err)
+
+	path, vars = http.SimplifyPath("/v1/{parent=shelves/*}/books/{ book.id=* }:move")
+	assert.Equal(t, "/v1/{parent}/books/{book.id}:move", path)
+	assert.Equal(t, []http.PathVar{{Name: "parent", Pattern: "shelves/*"}, {Name: "book.id"}}, vars)
+}
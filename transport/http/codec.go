@@ -1,14 +1,312 @@
 package http
 
 import (
+	"encoding/xml"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/dizzrt/ellie/encoding"
+	"github.com/dizzrt/ellie/encoding/form"
+	"github.com/dizzrt/ellie/encoding/json"
+	eproto "github.com/dizzrt/ellie/encoding/proto"
+	_ "github.com/dizzrt/ellie/encoding/toml"
+	_ "github.com/dizzrt/ellie/encoding/xml"
+	_ "github.com/dizzrt/ellie/encoding/yaml"
+	"github.com/dizzrt/ellie/errors"
 	"github.com/gin-gonic/gin/render"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
-type HTTPResponseEncoder = func(data any, err error, s *Server) (int, render.Render)
+type HTTPResponseEncoder = func(r *http.Request, data any, err error, s *Server) (int, render.Render)
+
+// Response is the envelope rendered by codecs without a dedicated layout,
+// e.g. xml and yaml. Reason and Metadata are only set for errors.
+type Response struct {
+	Data     any              `json:"data" xml:"data" yaml:"data" toml:"data"`
+	Status   int              `json:"status" xml:"status" yaml:"status" toml:"status"`
+	Message  string           `json:"message" xml:"message" yaml:"message" toml:"message"`
+	Reason   string           `json:"reason,omitempty" xml:"reason,omitempty" yaml:"reason,omitempty" toml:"reason,omitempty"`
+	Metadata ResponseMetadata `json:"metadata,omitempty" xml:"metadata,omitempty" yaml:"metadata,omitempty" toml:"metadata,omitempty"`
+}
+
+// ResponseMetadata is the error metadata of a Response, xml has no map type
+// so it's rendered as <entry key="..."> elements.
+type ResponseMetadata map[string]string
+
+type metadataEntries struct {
+	Entries []metadataEntry `xml:"entry"`
+}
+
+type metadataEntry struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (m ResponseMetadata) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	v := metadataEntries{Entries: make([]metadataEntry, 0, len(m))}
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		v.Entries = append(v.Entries, metadataEntry{Key: key, Value: m[key]})
+	}
+
+	return e.EncodeElement(v, start)
+}
+
+func (m *ResponseMetadata) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v metadataEntries
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	*m = make(ResponseMetadata, len(v.Entries))
+	for _, entry := range v.Entries {
+		(*m)[entry.Key] = entry.Value
+	}
+
+	return nil
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// NegotiateCodec picks the codec for the response from the Accept header of
+// r, json is used when nothing acceptable is registered.
+func NegotiateCodec(r *http.Request) (encoding.Codec, string) {
+	return negotiateCodec(r, true)
+}
+
+// negotiateCodec is NegotiateCodec, problem+json is only acceptable for
+// error responses, successful ones fall through to the next range.
+func negotiateCodec(r *http.Request, isError bool) (encoding.Codec, string) {
+	for _, ar := range parseAccept(r.Header.Get("Accept")) {
+		switch {
+		case ar.mediaType == "*/*":
+			return encoding.GetCodec(json.Name), "application/json"
+		case strings.HasSuffix(ar.mediaType, "/*"):
+			if codec, contentType := codecForRange(strings.TrimSuffix(ar.mediaType, "*"), isError); codec != nil {
+				return codec, contentType
+			}
+		case ar.mediaType == ProblemContentType && !isError:
+			continue
+		default:
+			if codec := encoding.GetCodecByContentType(ar.mediaType); codec != nil {
+				return codec, ar.mediaType
+			}
+		}
+	}
+
+	return encoding.GetCodec(json.Name), "application/json"
+}
+
+func codecForRange(prefix string, isError bool) (encoding.Codec, string) {
+	candidates := encoding.ContentTypes()
+	slices.Sort(candidates)
+	for _, contentType := range candidates {
+		if !strings.HasPrefix(contentType, prefix) || (contentType == ProblemContentType && !isError) {
+			continue
+		}
+
+		if codec := encoding.GetCodecByContentType(contentType); codec != nil {
+			return codec, contentType
+		}
+	}
+
+	return nil, ""
+}
+
+// parseAccept returns the acceptable media ranges ordered by quality.
+func parseAccept(accept string) []acceptRange {
+	if accept == "" {
+		return nil
+	}
+
+	ranges := make([]acceptRange, 0)
+	for part := range strings.SplitSeq(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mt, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
 
-func DefaultResponseEncoder(data any, err error, s *Server) (int, render.Render) {
+	return ranges
+}
+
+func DefaultResponseEncoder(r *http.Request, data any, err error, s *Server) (int, render.Render) {
 	code := HTTPStatusCodeFromError(err)
-	r := render.JSON{Data: s.WrapHTTPResponse(data, err)}
+	codec, contentType := negotiateCodec(r, err != nil)
+	if err != nil && contentType == ProblemContentType {
+		return ProblemResponseEncoder(r, data, err, s)
+	}
+
+	// protobuf can only render messages, other values are rendered as json
+	if _, ok := data.(proto.Message); codec.Name() == eproto.Name && err == nil && data != nil && !ok {
+		codec, contentType = encoding.GetCodec(json.Name), "application/json"
+	}
+
+	return code, &codecRender{
+		codec:       codec,
+		contentType: contentType,
+		data:        s.responseBody(codec, data, err),
+	}
+}
+
+func (s *Server) responseBody(codec encoding.Codec, data any, err error) any {
+	switch codec.Name() {
+	case eproto.Name:
+		if err != nil {
+			return s.errorCore(err)
+		}
+
+		if data == nil {
+			return &emptypb.Empty{}
+		}
+
+		return data
+	case json.Name:
+		body := s.WrapHTTPResponse(data, err)
+		if data != nil {
+			if raw, e := codec.Marshal(data); e == nil {
+				body["data"] = sonic.NoCopyRawMessage(raw)
+			}
+		}
+
+		return body
+	case form.Name:
+		// data fields are prefixed so they can't clash with the envelope
+		body := s.WrapHTTPResponse(nil, err)
+		values := url.Values{}
+		if data != nil {
+			if raw, e := codec.Marshal(data); e == nil {
+				fields, _ := url.ParseQuery(string(raw))
+				for k, v := range fields {
+					values["data."+k] = v
+				}
+			}
+		}
+
+		values.Set("status", strconv.Itoa(body["status"].(int)))
+		values.Set("message", body["message"].(string))
+		if reason, ok := body["reason"].(string); ok {
+			values.Set("reason", reason)
+		}
+
+		metadata, _ := body["metadata"].(map[string]string)
+		for k, v := range metadata {
+			values.Set("metadata["+k+"]", v)
+		}
+
+		return values
+	default:
+		body := s.WrapHTTPResponse(data, err)
+		res := &Response{
+			Data:    codecValue(codec, data),
+			Status:  body["status"].(int),
+			Message: body["message"].(string),
+		}
+
+		res.Reason, _ = body["reason"].(string)
+		res.Metadata, _ = body["metadata"].(map[string]string)
+		return res
+	}
+}
+
+// codecValue returns a proto message as the generic value the codec decodes
+// it to, codecs bridging messages through protojson such as yaml then render
+// the envelope data like the message alone. Codecs without generic values,
+// e.g. xml, render the message as is.
+func codecValue(codec encoding.Codec, data any) any {
+	if _, ok := data.(proto.Message); !ok {
+		return data
+	}
+
+	raw, err := codec.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	var generic any
+	if err = codec.Unmarshal(raw, &generic); err != nil || generic == nil {
+		return data
+	}
+
+	return generic
+}
+
+func (s *Server) errorCore(err error) *errors.ErrorCore {
+	body := s.WrapHTTPResponse(nil, err)
+	se := errors.NewStandardErrorFromError(err)
+	core := &errors.ErrorCore{
+		Code:     int32(body["status"].(int)),
+		Reason:   se.Reason(),
+		Message:  body["message"].(string),
+		Metadata: se.Metadata(),
+	}
+
+	if st := se.Status(); st != nil {
+		status := int32(*st)
+		core.Status = &status
+	}
+
+	return core
+}
+
+type codecRender struct {
+	codec       encoding.Codec
+	contentType string
+	data        any
+}
+
+func (r *codecRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	var body []byte
+	var err error
+	if values, ok := r.data.(url.Values); ok {
+		body = []byte(values.Encode())
+	} else if body, err = r.codec.Marshal(r.data); err != nil {
+		return err
+	}
+
+	_, err = w.Write(body)
+	return err
+}
+
+func (r *codecRender) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if header.Get("Content-Type") != "" {
+		return
+	}
+
+	contentType := r.contentType
+	if r.codec.Name() != eproto.Name && !strings.Contains(contentType, "charset") {
+		contentType += "; charset=utf-8"
+	}
 
-	return code, r
+	header.Set("Content-Type", contentType)
 }
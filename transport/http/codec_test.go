@@ -0,0 +1,226 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/errors"
+	"github.com/dizzrt/ellie/internal/mock/ping"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNegotiateCodec(t *testing.T) {
+	tests := []struct {
+		accept      string
+		codec       string
+		contentType string
+	}{
+		{"", "json", "application/json"},
+		{"*/*", "json", "application/json"},
+		{"application/x-protobuf", "proto", "application/x-protobuf"},
+		{"text/html, application/xml;q=0.9, application/json;q=0.8", "xml", "application/xml"},
+		{"application/json;q=0.5, application/yaml", "yaml", "application/yaml"},
+		{"application/problem+json", "json", "application/problem+json"},
+		{"text/*", "xml", "text/xml"},
+		{"application/msgpack, text/html", "json", "application/json"},
+		{"application/xml;q=0", "json", "application/json"},
+		{"application/toml", "toml", "application/toml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r, _ := nhttp.NewRequest(nhttp.MethodGet, "/", nil)
+			r.Header.Set("Accept", tt.accept)
+
+			codec, contentType := http.NegotiateCodec(r)
+			assert.Equal(t, tt.codec, codec.Name())
+			assert.Equal(t, tt.contentType, contentType)
+		})
+	}
+}
+
+func TestResponseContentNegotiation(t *testing.T) {
+	srv := startPingServer(t)
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+
+	get := func(accept string) (*nhttp.Response, []byte) {
+		req, err := nhttp.NewRequestWithContext(context.Background(), nhttp.MethodGet, e.String()+"/ping", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Accept", accept)
+
+		res, err := nhttp.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		return res, body
+	}
+
+	res, body := get("application/x-protobuf")
+	assert.Equal(t, "application/x-protobuf", res.Header.Get("Content-Type"))
+	reply := &ping.PingResponse{}
+	assert.NoError(t, proto.Unmarshal(body, reply))
+	assert.Equal(t, "pong", reply.GetMessage())
+
+	res, body = get("application/xml")
+	assert.Equal(t, "application/xml; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(string(body), "<Response>"), string(body))
+
+	res, body = get("application/yaml")
+	assert.Equal(t, "application/yaml; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "message: pong")
+
+	res, body = get("application/json")
+	assert.Equal(t, "application/json; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), `"data":{"message":"pong"}`)
+}
+
+func TestClientInvokeProtobuf(t *testing.T) {
+	ctx := context.Background()
+	srv := startPingServer(t)
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+
+	client, err := http.NewClient(ctx,
+		http.WithEndpoint(e.Host),
+		http.WithContentType("application/x-protobuf"),
+	)
+	assert.NoError(t, err)
+	defer client.Close()
+
+	reply := &ping.HelloResponse{}
+	err = client.Invoke(ctx, nhttp.MethodPost, "/hello/ellie", &ping.HelloRequest{Type: "mock"}, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello ellie, type is mock", reply.GetMessage())
+}
+
+func TestProblemResponse(t *testing.T) {
+	srv := startPingServer(t, http.ResponseEncoder(http.ProblemResponseEncoder))
+	srv.Engine().GET("/problem", func(ctx *gin.Context) {
+		scode := codes.NotFound
+		err := errors.NewStandardError(&scode, 40401, "USER_NOT_FOUND", "user not found").
+			WithMetadata(map[string]string{"user_id": "42", "status": "ignored", "code": "ignored", "reason": "ignored"})
+		srv.EncodeResponse(ctx, nil, err)
+	})
+
+	srv.Engine().GET("/grpc-problem", func(ctx *gin.Context) {
+		st, _ := status.New(codes.InvalidArgument, "bad name").WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "name", Description: "too long"}},
+		})
+		srv.EncodeResponse(ctx, nil, st.Err())
+	})
+
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+
+	res, err := nhttp.Get(e.String() + "/problem")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, nhttp.StatusNotFound, res.StatusCode)
+	assert.Equal(t, "application/problem+json; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "urn:problem-type:user-not-found",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "user not found",
+		"instance": "/problem",
+		"code": 40401,
+		"reason": "USER_NOT_FOUND",
+		"user_id": "42"
+	}`, string(body))
+
+	res, err = nhttp.Get(e.String() + "/grpc-problem")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err = io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, nhttp.StatusBadRequest, res.StatusCode)
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, []any{map[string]any{"name": "name", "reason": "too long"}}, doc["invalid-params"])
+
+	// successful responses keep the envelope
+	client, err := http.NewClient(context.Background(), http.WithEndpoint(e.Host))
+	assert.NoError(t, err)
+	defer client.Close()
+
+	reply := &ping.PingResponse{}
+	assert.NoError(t, client.Invoke(context.Background(), nhttp.MethodGet, "/ping", nil, reply))
+	assert.Equal(t, "pong", reply.GetMessage())
+
+	err = client.Invoke(context.Background(), nhttp.MethodGet, "/problem", nil, nil)
+	se, ok := err.(*errors.StandardError)
+	if assert.True(t, ok, "expected *errors.StandardError, got %T", err) {
+		assert.Equal(t, int32(40401), se.Code())
+		assert.Equal(t, "USER_NOT_FOUND", se.Reason())
+		assert.Equal(t, "42", se.Metadata()["user_id"])
+		assert.Equal(t, codes.NotFound, *se.Status())
+	}
+}
+
+func TestProblemByAccept(t *testing.T) {
+	srv := startPingServer(t)
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+
+	req, err := nhttp.NewRequest(nhttp.MethodGet, e.String()+"/error", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept", "application/problem+json")
+
+	res, err := nhttp.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	// errors rendered by EncodeResponse become problems
+	assert.Equal(t, nhttp.StatusNotFound, res.StatusCode)
+	assert.Contains(t, res.Header.Get("Content-Type"), "application/problem+json")
+
+	// successful responses are never problems, the next range or json is used
+	for accept, contentType := range map[string]string{
+		"application/problem+json":                        "application/json",
+		"application/problem+json, application/xml;q=0.5": "application/xml",
+	} {
+		req, err = nhttp.NewRequest(nhttp.MethodGet, e.String()+"/ping", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Accept", accept)
+
+		res, err = nhttp.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, nhttp.StatusOK, res.StatusCode)
+		assert.Equal(t, contentType+"; charset=utf-8", res.Header.Get("Content-Type"))
+	}
+
+	req, err = nhttp.NewRequest(nhttp.MethodPost, e.String()+"/hello/ellie", strings.NewReader("{"))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/problem+json")
+
+	res, err = nhttp.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, nhttp.StatusBadRequest, res.StatusCode)
+	assert.Contains(t, res.Header.Get("Content-Type"), "application/problem+json")
+	assert.Contains(t, string(body), `"type":"urn:problem-type:invalid-argument"`)
+}
@@ -0,0 +1,390 @@
+package filters
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	thttp "github.com/dizzrt/ellie/transport/http"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
+
+	defaultCompressMinSize = 1024
+)
+
+var defaultCompressContentTypes = []string{
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/xml",
+	"text/javascript",
+	"application/javascript",
+	"application/json",
+	"application/problem+json",
+	"application/xml",
+	"application/x-www-form-urlencoded",
+	"image/svg+xml",
+}
+
+type compressConfig struct {
+	encodings    []string
+	minSize      int
+	contentTypes []string
+	level        int
+
+	pools map[string]*sync.Pool
+}
+
+type CompressOption func(*compressConfig)
+
+// CompressEncodings sets the supported encodings in order of preference, it
+// breaks ties between encodings the client accepts with the same quality. The
+// default is zstd, gzip and deflate.
+func CompressEncodings(encodings ...string) CompressOption {
+	return func(c *compressConfig) {
+		c.encodings = encodings
+	}
+}
+
+// CompressMinSize sets the size below which responses are sent uncompressed,
+// the default is 1KB.
+func CompressMinSize(size int) CompressOption {
+	return func(c *compressConfig) {
+		c.minSize = size
+	}
+}
+
+// CompressContentTypes sets the media types that are compressed, a trailing
+// "/*" matches any subtype, e.g. "text/*".
+func CompressContentTypes(types ...string) CompressOption {
+	return func(c *compressConfig) {
+		c.contentTypes = types
+	}
+}
+
+// CompressLevel sets the gzip and deflate level, zstd levels are mapped to
+// the closest encoder level.
+func CompressLevel(level int) CompressOption {
+	return func(c *compressConfig) {
+		c.level = level
+	}
+}
+
+// Compress compresses responses with the encoding negotiated by
+// Accept-Encoding. Responses that are small, of other content types, already
+// encoded, partial or sent with Cache-Control: no-transform are left as is.
+func Compress(opts ...CompressOption) thttp.FilterFunc {
+	conf := &compressConfig{
+		encodings:    []string{EncodingZstd, EncodingGzip, EncodingDeflate},
+		minSize:      defaultCompressMinSize,
+		contentTypes: defaultCompressContentTypes,
+		level:        gzip.DefaultCompression,
+	}
+
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	// unknown encodings and invalid levels are skipped
+	conf.pools = make(map[string]*sync.Pool, len(conf.encodings))
+	for _, e := range conf.encodings {
+		pool := &sync.Pool{New: conf.newEncoder(e)}
+		if w := pool.Get(); w != nil {
+			pool.Put(w)
+			conf.pools[e] = pool
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			encoding := conf.negotiate(r.Header.Get("Accept-Encoding"))
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, conf: conf, encoding: encoding, status: http.StatusOK}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+type resetWriter interface {
+	io.WriteCloser
+	Reset(io.Writer)
+	Flush() error
+}
+
+func (c *compressConfig) newEncoder(encoding string) func() any {
+	return func() any {
+		var (
+			w   resetWriter
+			err error
+		)
+
+		switch encoding {
+		case EncodingGzip:
+			w, err = gzip.NewWriterLevel(io.Discard, c.level)
+		case EncodingDeflate:
+			w, err = zlib.NewWriterLevel(io.Discard, c.level)
+		case EncodingZstd:
+			level := zstd.SpeedDefault
+			if c.level != gzip.DefaultCompression {
+				level = zstd.EncoderLevelFromZstd(c.level)
+			}
+
+			w, err = zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+		default:
+			return nil
+		}
+
+		if err != nil {
+			return nil
+		}
+
+		return w
+	}
+}
+
+// negotiate picks the accepted encoding with the highest quality, ties are
+// broken by the configured order.
+func (c *compressConfig) negotiate(accept string) string {
+	if accept == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+	for _, v := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(v, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if qv, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(qv, 64)
+			if err != nil {
+				continue
+			}
+
+			q = f
+		}
+
+		if name == "*" {
+			wildcard = q
+		} else {
+			qualities[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, e := range c.encodings {
+		q, ok := qualities[e]
+		if !ok {
+			q = wildcard
+		}
+
+		if q > bestQ && c.pools[e] != nil {
+			best, bestQ = e, q
+		}
+	}
+
+	return best
+}
+
+func (c *compressConfig) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(c.contentTypes, func(t string) bool {
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			return strings.HasPrefix(mediaType, prefix+"/")
+		}
+
+		return mediaType == t
+	})
+}
+
+// compressWriter buffers the start of the response until the minimum size is
+// reached, the response is flushed or ends, and then decides whether to
+// compress it.
+type compressWriter struct {
+	http.ResponseWriter
+
+	conf     *compressConfig
+	encoding string
+	status   int
+	buf      []byte
+	decided  bool
+	flushed  bool
+	hijacked bool
+	enc      resetWriter
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided {
+		return
+	}
+
+	// informational responses are sent right away
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	cw.status = code
+	if code == http.StatusSwitchingProtocols || !bodyAllowed(code) {
+		_ = cw.decide()
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.conf.minSize {
+			return len(p), nil
+		}
+
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+
+		return len(p), nil
+	}
+
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends what was written so far, a flushed response is compressed
+// regardless of its size since more data is expected.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.flushed = true
+		_ = cw.decide()
+	}
+
+	if cw.enc != nil {
+		_ = cw.enc.Flush()
+	}
+
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	cw.decided, cw.hijacked = true, true
+	return hj.Hijack()
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+	h := cw.Header()
+	if cw.shouldCompress(h) {
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		h.Set("Content-Encoding", cw.encoding)
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+
+		cw.enc = cw.conf.pools[cw.encoding].Get().(resetWriter)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) == 0 {
+		return nil
+	}
+
+	buf := cw.buf
+	cw.buf = nil
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+func (cw *compressWriter) shouldCompress(h http.Header) bool {
+	if !bodyAllowed(cw.status) || cw.status == http.StatusPartialContent || cw.status == http.StatusSwitchingProtocols {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" && len(cw.buf) > 0 {
+		contentType = http.DetectContentType(cw.buf)
+	}
+
+	if !cw.conf.compressible(contentType) {
+		return false
+	}
+
+	h.Add("Vary", "Accept-Encoding")
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+
+	if strings.Contains(strings.ToLower(h.Get("Cache-Control")), "no-transform") {
+		return false
+	}
+
+	return cw.flushed || len(cw.buf) >= cw.conf.minSize
+}
+
+func (cw *compressWriter) close() {
+	if cw.hijacked {
+		return
+	}
+
+	if !cw.decided {
+		_ = cw.decide()
+	}
+
+	if cw.enc != nil {
+		_ = cw.enc.Close()
+		cw.enc.Reset(io.Discard)
+		cw.conf.pools[cw.encoding].Put(cw.enc)
+		cw.enc = nil
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,185 @@
+package filters
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	thttp "github.com/dizzrt/ellie/transport/http"
+)
+
+type corsConfig struct {
+	allowedOrigins   []string
+	allowOriginFunc  func(origin string) bool
+	allowedMethods   []string
+	allowedHeaders   []string
+	exposedHeaders   []string
+	allowCredentials bool
+	maxAge           time.Duration
+	grpcWeb          bool
+}
+
+type CORSOption func(*corsConfig)
+
+// AllowOrigins sets the allowed origins, "*" allows any origin and a single
+// wildcard is supported inside an origin, e.g. "https://*.example.com".
+func AllowOrigins(origins ...string) CORSOption {
+	return func(c *corsConfig) {
+		c.allowedOrigins = origins
+	}
+}
+
+// AllowOriginFunc sets a custom origin check, it is consulted when the origin
+// does not match AllowOrigins.
+func AllowOriginFunc(fn func(origin string) bool) CORSOption {
+	return func(c *corsConfig) {
+		c.allowOriginFunc = fn
+	}
+}
+
+func AllowMethods(methods ...string) CORSOption {
+	return func(c *corsConfig) {
+		c.allowedMethods = methods
+	}
+}
+
+func AllowHeaders(headers ...string) CORSOption {
+	return func(c *corsConfig) {
+		c.allowedHeaders = headers
+	}
+}
+
+func ExposeHeaders(headers ...string) CORSOption {
+	return func(c *corsConfig) {
+		c.exposedHeaders = headers
+	}
+}
+
+func AllowCredentials(allow bool) CORSOption {
+	return func(c *corsConfig) {
+		c.allowCredentials = allow
+	}
+}
+
+// MaxAge sets how long the result of a preflight request can be cached.
+func MaxAge(maxAge time.Duration) CORSOption {
+	return func(c *corsConfig) {
+		c.maxAge = maxAge
+	}
+}
+
+// AllowGRPCWeb allows and exposes the headers used by gRPC-Web and Connect
+// clients in addition to the configured ones, see thttp.MountGRPC.
+func AllowGRPCWeb() CORSOption {
+	return func(c *corsConfig) {
+		c.grpcWeb = true
+	}
+}
+
+func CORS(opts ...CORSOption) thttp.FilterFunc {
+	conf := &corsConfig{
+		allowedOrigins: []string{"*"},
+		allowedMethods: []string{
+			http.MethodGet, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodHead,
+		},
+		allowedHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Log-ID"},
+	}
+
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	if conf.grpcWeb {
+		conf.allowedHeaders = slices.Clone(conf.allowedHeaders)
+		conf.allowedMethods = slices.Clone(conf.allowedMethods)
+		for _, h := range thttp.GRPCWebAllowedHeaders {
+			if !slices.ContainsFunc(conf.allowedHeaders, func(allowed string) bool { return strings.EqualFold(allowed, h) }) {
+				conf.allowedHeaders = append(conf.allowedHeaders, h)
+			}
+		}
+
+		conf.exposedHeaders = slices.Concat(conf.exposedHeaders, thttp.GRPCWebExposedHeaders)
+		if !slices.Contains(conf.allowedMethods, http.MethodPost) {
+			conf.allowedMethods = append(conf.allowedMethods, http.MethodPost)
+		}
+	}
+
+	allowedMethods := strings.Join(conf.allowedMethods, ", ")
+	allowedHeaders := strings.Join(conf.allowedHeaders, ", ")
+	exposedHeaders := strings.Join(conf.exposedHeaders, ", ")
+	allowAnyOrigin := slices.Contains(conf.allowedOrigins, "*")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			header := w.Header()
+			header.Add("Vary", "Origin")
+			if origin == "" || !conf.isOriginAllowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowAnyOrigin && !conf.allowCredentials {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				// the wildcard is not allowed together with credentials
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+
+			if conf.allowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			isPreflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !isPreflight {
+				if exposedHeaders != "" {
+					header.Set("Access-Control-Expose-Headers", exposedHeaders)
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			if !slices.Contains(conf.allowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			header.Set("Access-Control-Allow-Methods", allowedMethods)
+			if allowedHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowedHeaders)
+			}
+
+			if conf.maxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(conf.maxAge.Seconds())))
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func (c *corsConfig) isOriginAllowed(origin string) bool {
+	for _, allowed := range c.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok {
+			if len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+
+	if c.allowOriginFunc != nil {
+		return c.allowOriginFunc(origin)
+	}
+
+	return false
+}
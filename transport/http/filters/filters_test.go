@@ -0,0 +1,204 @@
+package filters
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	thttp "github.com/dizzrt/ellie/transport/http"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func serve(filter thttp.FilterFunc, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	filter(okHandler).ServeHTTP(w, r)
+	return w
+}
+
+func TestCORS(t *testing.T) {
+	filter := CORS(
+		AllowOrigins("https://*.ellie.dev"),
+		AllowCredentials(true),
+		ExposeHeaders("X-Log-ID"),
+		MaxAge(10*time.Minute),
+	)
+
+	// simple request
+	r := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	r.Header.Set("Origin", "https://app.ellie.dev")
+	w := serve(filter, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.ellie.dev", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "X-Log-ID", w.Header().Get("Access-Control-Expose-Headers"))
+
+	// preflight request
+	r = httptest.NewRequest(http.MethodOptions, "/ping", nil)
+	r.Header.Set("Origin", "https://app.ellie.dev")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w = serve(filter, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+
+	// disallowed origin
+	r = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	w = serve(filter, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	filter := SecurityHeaders(
+		HSTS(365*24*time.Hour, true, false),
+		ContentSecurityPolicy("default-src 'self'"),
+	)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := serve(filter, r)
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "default-src 'self'", w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+	// X-Forwarded-Proto is only trusted from the proxies of ClientIP
+	r.Header.Set("X-Forwarded-Proto", "https")
+	w = serve(filter, r)
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+
+	w = serve(thttp.FilterChain(ClientIP(TrustedProxies("10.0.0.0/8")), filter), r)
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+
+	r.RemoteAddr = "10.0.0.1:1234"
+	w = serve(thttp.FilterChain(ClientIP(TrustedProxies("10.0.0.0/8")), filter), r)
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+}
+
+func TestClientIPAndIPFilter(t *testing.T) {
+	chain := thttp.FilterChain(
+		ClientIP(TrustedProxies("10.0.0.0/8")),
+		IPFilter(AllowCIDRs("192.168.0.0/16"), DenyCIDRs("192.168.1.1")),
+	)
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		code   int
+	}{
+		{"direct allowed", "192.168.2.1:1234", "", http.StatusOK},
+		{"direct denied", "192.168.1.1:1234", "", http.StatusForbidden},
+		{"untrusted proxy header ignored", "8.8.8.8:1234", "192.168.2.1", http.StatusForbidden},
+		{"trusted proxy chain", "10.0.0.1:1234", "1.1.1.1, 192.168.2.1, 10.0.0.2", http.StatusOK},
+		{"trusted proxy denied client", "10.0.0.1:1234", "192.168.1.1", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+
+			w := serve(chain, r)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
+
+func TestCORSAllowGRPCWeb(t *testing.T) {
+	filter := CORS(AllowMethods(http.MethodGet), AllowGRPCWeb())
+
+	r := httptest.NewRequest(http.MethodOptions, "/ping.PingService/Ping", nil)
+	r.Header.Set("Origin", "https://app.ellie.dev")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := serve(filter, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "X-Grpc-Web")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Connect-Protocol-Version")
+
+	r = httptest.NewRequest(http.MethodPost, "/ping.PingService/Ping", nil)
+	r.Header.Set("Origin", "https://app.ellie.dev")
+	w = serve(filter, r)
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Grpc-Status")
+}
+
+func TestCompress(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("ellie", 400) + `"}`
+	respond := func(contentType, body string, header map[string]string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			for k, v := range header {
+				w.Header().Set(k, v)
+			}
+
+			_, _ = io.WriteString(w, body)
+		})
+	}
+
+	get := func(h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/data", nil)
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		Compress()(h).ServeHTTP(w, r)
+		return w
+	}
+
+	w := get(respond("application/json", large, map[string]string{"ETag": `"v1"`}), "gzip, deflate")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Equal(t, `W/"v1"`, w.Header().Get("ETag"))
+	gr, err := gzip.NewReader(w.Body)
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(gr)
+		assert.Equal(t, large, string(data))
+	}
+
+	// zstd is preferred on ties, quality wins otherwise
+	w = get(respond("application/json", large, nil), "gzip, zstd")
+	assert.Equal(t, "zstd", w.Header().Get("Content-Encoding"))
+	zr, err := zstd.NewReader(w.Body)
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(zr)
+		assert.Equal(t, large, string(data))
+		zr.Close()
+	}
+
+	w = get(respond("application/json", large, nil), "gzip;q=1, zstd;q=0.5")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	// responses left as is
+	for name, h := range map[string]http.Handler{
+		"small":        respond("application/json", `{"data":"ellie"}`, nil),
+		"content type": respond("image/png", large, nil),
+		"encoded":      respond("application/json", large, map[string]string{"Content-Encoding": "br"}),
+		"no-transform": respond("application/json", large, map[string]string{"Cache-Control": "no-transform"}),
+	} {
+		w = get(h, "gzip")
+		assert.NotEqual(t, "gzip", w.Header().Get("Content-Encoding"), name)
+	}
+
+	w = get(respond("application/json", large, nil), "identity")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, w.Body.String())
+
+	// flushed responses are compressed right away
+	w = get(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, "chunk")
+		w.(http.Flusher).Flush()
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	}), "gzip")
+	assert.True(t, w.Flushed)
+}
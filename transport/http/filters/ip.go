@@ -0,0 +1,214 @@
+package filters
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	thttp "github.com/dizzrt/ellie/transport/http"
+)
+
+type clientIPKey struct{}
+
+type trustedProxyKey struct{}
+
+type clientIPConfig struct {
+	trustedProxies []netip.Prefix
+	headers        []string
+}
+
+type ClientIPOption func(*clientIPConfig)
+
+// TrustedProxies sets the proxies whose forwarding headers are trusted, both
+// CIDRs and single IPs are accepted.
+func TrustedProxies(proxies ...string) ClientIPOption {
+	return func(c *clientIPConfig) {
+		c.trustedProxies = mustParsePrefixes(proxies)
+	}
+}
+
+// ClientIPHeaders sets the forwarding headers checked in order, the default
+// is X-Forwarded-For then X-Real-IP.
+func ClientIPHeaders(headers ...string) ClientIPOption {
+	return func(c *clientIPConfig) {
+		c.headers = headers
+	}
+}
+
+// ClientIP resolves the real client address and stores it in the request
+// context, see ClientIPFromContext. Forwarding headers are only honored when
+// the direct peer is a trusted proxy, filters that follow use the same rule
+// for headers such as X-Forwarded-Proto.
+func ClientIP(opts ...ClientIPOption) thttp.FilterFunc {
+	conf := &clientIPConfig{
+		headers: []string{"X-Forwarded-For", "X-Real-IP"},
+	}
+
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip, ok := conf.resolve(r); ok {
+				ctx := context.WithValue(r.Context(), clientIPKey{}, ip)
+				if remote, _ := remoteAddr(r); containsAddr(conf.trustedProxies, remote) {
+					ctx = context.WithValue(ctx, trustedProxyKey{}, true)
+				}
+
+				r = r.WithContext(ctx)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIPFromContext returns the address resolved by the ClientIP filter.
+func ClientIPFromContext(ctx context.Context) (netip.Addr, bool) {
+	ip, ok := ctx.Value(clientIPKey{}).(netip.Addr)
+	return ip, ok
+}
+
+// fromTrustedProxy reports whether a preceding ClientIP filter found the direct
+// peer in its trusted proxies.
+func fromTrustedProxy(ctx context.Context) bool {
+	trusted, _ := ctx.Value(trustedProxyKey{}).(bool)
+	return trusted
+}
+
+func (c *clientIPConfig) resolve(r *http.Request) (netip.Addr, bool) {
+	remote, ok := remoteAddr(r)
+	if !ok || !containsAddr(c.trustedProxies, remote) {
+		return remote, ok
+	}
+
+	for _, name := range c.headers {
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		// walk the chain from the nearest hop, the first untrusted hop is the client
+		hops := strings.Split(strings.Join(values, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+
+			ip = ip.Unmap()
+			if i == 0 || !containsAddr(c.trustedProxies, ip) {
+				return ip, true
+			}
+		}
+	}
+
+	return remote, true
+}
+
+type ipFilterConfig struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+type IPFilterOption func(*ipFilterConfig)
+
+// AllowCIDRs restricts access to the given networks, an empty list allows all.
+func AllowCIDRs(cidrs ...string) IPFilterOption {
+	return func(c *ipFilterConfig) {
+		c.allow = append(c.allow, mustParsePrefixes(cidrs)...)
+	}
+}
+
+// DenyCIDRs rejects the given networks, deny rules win over allow rules.
+func DenyCIDRs(cidrs ...string) IPFilterOption {
+	return func(c *ipFilterConfig) {
+		c.deny = append(c.deny, mustParsePrefixes(cidrs)...)
+	}
+}
+
+// IPFilter rejects requests with 403 by client address, it uses the address
+// resolved by a preceding ClientIP filter and falls back to the peer address.
+func IPFilter(opts ...IPFilterOption) thttp.FilterFunc {
+	conf := &ipFilterConfig{}
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, ok := ClientIPFromContext(r.Context())
+			if !ok {
+				ip, ok = remoteAddr(r)
+			}
+
+			if !ok || !conf.isAllowed(ip) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (c *ipFilterConfig) isAllowed(ip netip.Addr) bool {
+	if containsAddr(c.deny, ip) {
+		return false
+	}
+
+	return len(c.allow) == 0 || containsAddr(c.allow, ip)
+}
+
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return ip.Unmap(), true
+}
+
+func containsAddr(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mustParsePrefixes(cidrs []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip, err := netip.ParseAddr(cidr)
+			if err != nil {
+				panic(fmt.Sprintf("invalid ip address %q: %v", cidr, err))
+			}
+
+			ip = ip.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("invalid cidr %q: %v", cidr, err))
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes
+}
@@ -0,0 +1,104 @@
+package filters
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	thttp "github.com/dizzrt/ellie/transport/http"
+)
+
+type securityConfig struct {
+	hsts                  string
+	contentSecurityPolicy string
+	frameOptions          string
+	referrerPolicy        string
+	noSniff               bool
+}
+
+type SecurityOption func(*securityConfig)
+
+// HSTS enables Strict-Transport-Security, it is only sent over TLS or when a
+// trusted proxy forwarded the request with X-Forwarded-Proto: https. Proxies
+// are trusted by a preceding ClientIP filter, see TrustedProxies.
+func HSTS(maxAge time.Duration, includeSubdomains, preload bool) SecurityOption {
+	return func(c *securityConfig) {
+		c.hsts = fmt.Sprintf("max-age=%d", int(maxAge.Seconds()))
+		if includeSubdomains {
+			c.hsts += "; includeSubDomains"
+		}
+
+		if preload {
+			c.hsts += "; preload"
+		}
+	}
+}
+
+func ContentSecurityPolicy(policy string) SecurityOption {
+	return func(c *securityConfig) {
+		c.contentSecurityPolicy = policy
+	}
+}
+
+// FrameOptions sets X-Frame-Options, e.g. "DENY" or "SAMEORIGIN", an empty
+// value disables the header.
+func FrameOptions(option string) SecurityOption {
+	return func(c *securityConfig) {
+		c.frameOptions = option
+	}
+}
+
+func ReferrerPolicy(policy string) SecurityOption {
+	return func(c *securityConfig) {
+		c.referrerPolicy = policy
+	}
+}
+
+func ContentTypeNoSniff(enabled bool) SecurityOption {
+	return func(c *securityConfig) {
+		c.noSniff = enabled
+	}
+}
+
+func SecurityHeaders(opts ...SecurityOption) thttp.FilterFunc {
+	conf := &securityConfig{
+		frameOptions:   "DENY",
+		referrerPolicy: "strict-origin-when-cross-origin",
+		noSniff:        true,
+	}
+
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			if conf.hsts != "" && (r.TLS != nil || isForwardedHTTPS(r)) {
+				header.Set("Strict-Transport-Security", conf.hsts)
+			}
+
+			if conf.contentSecurityPolicy != "" {
+				header.Set("Content-Security-Policy", conf.contentSecurityPolicy)
+			}
+
+			if conf.frameOptions != "" {
+				header.Set("X-Frame-Options", conf.frameOptions)
+			}
+
+			if conf.referrerPolicy != "" {
+				header.Set("Referrer-Policy", conf.referrerPolicy)
+			}
+
+			if conf.noSniff {
+				header.Set("X-Content-Type-Options", "nosniff")
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isForwardedHTTPS(r *http.Request) bool {
+	return fromTrustedProxy(r.Context()) && r.Header.Get("X-Forwarded-Proto") == "https"
+}
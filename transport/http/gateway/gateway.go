@@ -0,0 +1,446 @@
+// Package gateway serves the google.api.http routes of grpc services at
+// runtime. Routes are built from the service descriptors, requests are
+// decoded like in generated handlers and forwarded to the grpc service, the
+// replies are rendered with the response encoder of the http server.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dizzrt/ellie/log"
+	thttp "github.com/dizzrt/ellie/transport/http"
+	"github.com/dizzrt/ellie/transport/http/ginx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	tracerName = "ellie/transport/http/gateway"
+
+	// headers with this prefix are forwarded as grpc metadata without it
+	metadataHeaderPrefix = "Grpc-Metadata-"
+)
+
+// Gateway registers the routes of grpc services on an http server, the calls
+// are sent over conn.
+type Gateway struct {
+	hs   *thttp.Server
+	conn grpc.ClientConnInterface
+
+	omitempty       bool
+	omitemptyPrefix string
+	websocket       bool
+}
+
+type Option func(*Gateway)
+
+// OmitEmpty skips methods without a google.api.http rule, it is enabled by
+// default. Otherwise they are served on POST prefix/package.Service/Method
+// with the whole request as body.
+func OmitEmpty(omitempty bool) Option {
+	return func(g *Gateway) {
+		g.omitempty = omitempty
+	}
+}
+
+// OmitEmptyPrefix sets the path prefix of methods without a rule.
+func OmitEmptyPrefix(prefix string) Option {
+	return func(g *Gateway) {
+		g.omitemptyPrefix = prefix
+	}
+}
+
+// WebSocket serves client and bidi streaming methods over websocket on GET,
+// they are skipped by default.
+func WebSocket(enable bool) Option {
+	return func(g *Gateway) {
+		g.websocket = enable
+	}
+}
+
+func New(hs *thttp.Server, conn grpc.ClientConnInterface, opts ...Option) *Gateway {
+	g := &Gateway{
+		hs:        hs,
+		conn:      conn,
+		omitempty: true,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// Register adds the routes of services to the http server. It fails when a
+// path variable doesn't name a field of the request or a route conflicts
+// with one that is already registered.
+func (g *Gateway) Register(services ...protoreflect.ServiceDescriptor) (err error) {
+	defer func() {
+		// gin panics on conflicting routes
+		if r := recover(); r != nil {
+			err = fmt.Errorf("gateway: %v", r)
+		}
+	}()
+
+	for _, sd := range services {
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			if err = g.registerMethod(sd, methods.Get(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (g *Gateway) registerMethod(sd protoreflect.ServiceDescriptor, md protoreflect.MethodDescriptor) error {
+	if md.IsStreamingClient() && !g.websocket {
+		return nil
+	}
+
+	rule, _ := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+	if rule == nil {
+		if g.omitempty {
+			return nil
+		}
+
+		rule = &annotations.HttpRule{Body: "*"}
+	}
+
+	for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		method, path := httpMethodAndPath(r)
+		if path == "" {
+			path = fmt.Sprintf("%s/%s/%s", g.omitemptyPrefix, sd.FullName(), md.Name())
+		}
+
+		if md.IsStreamingClient() {
+			method = http.MethodGet
+		}
+
+		path, vars, err := routePath(md, path)
+		if err != nil {
+			return err
+		}
+
+		var responseBody protoreflect.FieldDescriptor
+		if name := r.GetResponseBody(); name != "" && !md.IsStreamingClient() && !md.IsStreamingServer() {
+			if responseBody = md.Output().Fields().ByName(protoreflect.Name(name)); responseBody == nil {
+				return fmt.Errorf("gateway: response_body %s not found in %s", name, md.Output().FullName())
+			}
+		}
+
+		for _, ri := range g.hs.Routes() {
+			if ri.Method == method && ri.Path == path {
+				return fmt.Errorf("gateway: route %s %s of %s is already registered", method, path, md.FullName())
+			}
+		}
+
+		operation := fmt.Sprintf("/%s/%s", sd.Name(), md.Name())
+		opts := append([]ginx.DecodeOption{ginx.Body(r.GetBody())}, vars...)
+		g.hs.Route(method, path, operation, g.handler(md, path, responseBody, opts))
+	}
+
+	return nil
+}
+
+func httpMethodAndPath(rule *annotations.HttpRule) (string, string) {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		if kind := pattern.Custom.GetKind(); kind != "" {
+			return kind, pattern.Custom.GetPath()
+		}
+
+		return http.MethodPost, pattern.Custom.GetPath()
+	}
+
+	return http.MethodPost, ""
+}
+
+// routePath converts the path template into a router path with
+// thttp.RoutePath, the variables must be fields of the request.
+func routePath(md protoreflect.MethodDescriptor, path string) (string, []ginx.DecodeOption, error) {
+	path, pathVars, err := thttp.RoutePath(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("gateway: %w", err)
+	}
+
+	var vars []ginx.DecodeOption
+	for _, v := range pathVars {
+		fields := md.Input().Fields()
+		for _, name := range strings.Split(v.Name, ".") {
+			if fields == nil {
+				return "", nil, fmt.Errorf("gateway: path variable %s of %s is not a message field", v.Name, md.FullName())
+			}
+
+			fd := fields.ByName(protoreflect.Name(name))
+			if fd == nil {
+				return "", nil, fmt.Errorf("gateway: path variable %s not found in %s", v.Name, md.Input().FullName())
+			}
+
+			fields = nil
+			if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+				fields = fd.Message().Fields()
+			}
+		}
+
+		if v.Pattern != "" {
+			vars = append(vars, ginx.PathVar(v.Name, v.Pattern))
+		}
+	}
+
+	return path, vars, nil
+}
+
+func (g *Gateway) handler(md protoreflect.MethodDescriptor, route string, responseBody protoreflect.FieldDescriptor, opts []ginx.DecodeOption) http.HandlerFunc {
+	switch {
+	case md.IsStreamingClient():
+		return g.webSocketHandler(md, route)
+	case md.IsStreamingServer():
+		return g.serverStreamHandler(md, route, opts)
+	}
+
+	return g.unaryHandler(md, route, responseBody, opts)
+}
+
+func (g *Gateway) unaryHandler(md protoreflect.MethodDescriptor, route string, responseBody protoreflect.FieldDescriptor, opts []ginx.DecodeOption) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := dynamicpb.NewMessage(md.Input())
+		r, err := ginx.DecodeHTTPRequest(r, req, opts...)
+		if err != nil {
+			g.hs.WriteResponse(w, r, nil, err)
+			return
+		}
+
+		r, span := startSpan(r, route, md, propagation.HeaderCarrier(r.Header))
+		defer span.End()
+
+		res := dynamicpb.NewMessage(md.Output())
+		if err := g.conn.Invoke(r.Context(), fullMethod(md), req, res); err != nil {
+			g.hs.WriteResponse(w, r, nil, err)
+			return
+		}
+
+		if responseBody == nil {
+			g.hs.WriteResponse(w, r, res, nil)
+			return
+		}
+
+		data, err := responseField(res, responseBody)
+		g.hs.WriteResponse(w, r, data, err)
+	}
+}
+
+// responseField returns the response_body field of res, fields other than
+// messages are rendered with their protojson representation.
+func responseField(res *dynamicpb.Message, fd protoreflect.FieldDescriptor) (any, error) {
+	if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+		return res.Get(fd).Message().Interface(), nil
+	}
+
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields[fd.JSONName()], nil
+}
+
+func (g *Gateway) serverStreamHandler(md protoreflect.MethodDescriptor, route string, opts []ginx.DecodeOption) http.HandlerFunc {
+	desc := &grpc.StreamDesc{StreamName: string(md.Name()), ServerStreams: true}
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := dynamicpb.NewMessage(md.Input())
+		r, err := ginx.DecodeHTTPRequest(r, req, opts...)
+		if err != nil {
+			g.hs.WriteResponse(w, r, nil, err)
+			return
+		}
+
+		r, span := startSpan(r, route, md, propagation.HeaderCarrier(r.Header))
+		defer span.End()
+
+		thttp.ServeServerStream(g.hs, w, r, func(ss grpc.ServerStreamingServer[dynamicpb.Message]) error {
+			cs, err := g.conn.NewStream(ss.Context(), desc, fullMethod(md))
+			if err != nil {
+				return err
+			}
+
+			if err = cs.SendMsg(req); err != nil && err != io.EOF {
+				return err
+			}
+
+			if err = cs.CloseSend(); err != nil {
+				return err
+			}
+
+			return forward(cs, ss, md.Output())
+		})
+	}
+}
+
+func (g *Gateway) webSocketHandler(md protoreflect.MethodDescriptor, route string) http.HandlerFunc {
+	desc := &grpc.StreamDesc{
+		StreamName:    string(md.Name()),
+		ClientStreams: true,
+		ServerStreams: md.IsStreamingServer(),
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		r, span := startSpan(r, route, md, thttp.UpgradeCarrier(r))
+		defer span.End()
+
+		thttp.ServeWebSocket(g.hs, w, r, func(ws *grpc.GenericServerStream[dynamicpb.Message, dynamicpb.Message]) error {
+			sctx, cancel := context.WithCancel(ws.Context())
+			defer cancel()
+
+			cs, err := g.conn.NewStream(sctx, desc, fullMethod(md))
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				for {
+					req := dynamicpb.NewMessage(md.Input())
+					if err := ws.RecvMsg(req); err != nil {
+						if err == io.EOF {
+							_ = cs.CloseSend()
+						} else {
+							cancel()
+						}
+
+						return
+					}
+
+					if err := cs.SendMsg(req); err != nil {
+						// the status is reported by RecvMsg
+						return
+					}
+				}
+			}()
+
+			return forward(cs, ws, md.Output())
+		})
+	}
+}
+
+// forward sends the replies received on cs to ss until the end of the stream.
+func forward(cs grpc.ClientStream, ss grpc.ServerStream, output protoreflect.MessageDescriptor) error {
+	for {
+		res := dynamicpb.NewMessage(output)
+		if err := cs.RecvMsg(res); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := ss.SendMsg(res); err != nil {
+			return err
+		}
+	}
+}
+
+// startSpan starts the server span of the route and returns the request with
+// the log and trace context stored in its context and in the outgoing grpc
+// metadata. The Authorization header and Grpc-Metadata-* headers are
+// forwarded as metadata.
+func startSpan(greq *http.Request, route string, md protoreflect.MethodDescriptor, carrier propagation.TextMapCarrier) (*http.Request, trace.Span) {
+	rctx := greq.Context()
+	rctx = otel.GetTextMapPropagator().Extract(rctx, carrier)
+	rctx = log.ExtractFromTextMapCarrier(rctx, carrier)
+	attributes := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(greq.Method),
+		semconv.HTTPRouteKey.String(route),
+		attribute.String("rpc.method", fullMethod(md)),
+		attribute.String("log.id", log.LogIDFromContext(rctx)),
+	}
+
+	tracer := otel.Tracer(tracerName)
+	rctx, span := tracer.Start(rctx, fmt.Sprintf("%s/%s", md.Parent().Name(), md.Name()),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attributes...),
+	)
+
+	sctx := span.SpanContext()
+	rctx = log.WithTraceID(rctx, sctx.TraceID().String())
+	rctx = log.WithSpanID(rctx, sctx.SpanID().String())
+
+	outgoing := metadata.MD{}
+	for k, vs := range greq.Header {
+		if name, ok := strings.CutPrefix(k, metadataHeaderPrefix); ok {
+			outgoing.Append(name, vs...)
+		}
+	}
+
+	if auth := greq.Header.Values("Authorization"); len(auth) > 0 {
+		outgoing.Append("authorization", auth...)
+	}
+
+	otel.GetTextMapPropagator().Inject(rctx, metadataCarrier(outgoing))
+	if logID := log.LogIDFromContext(rctx); logID != "" {
+		outgoing.Set("log.id", logID)
+	}
+
+	rctx = metadata.NewOutgoingContext(rctx, outgoing)
+	return greq.WithContext(rctx), span
+}
+
+func fullMethod(md protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+}
+
+// metadataCarrier adapts grpc metadata to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if vs := metadata.MD(mc).Get(key); len(vs) > 0 {
+		return vs[0]
+	}
+
+	return ""
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range mc {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
@@ -0,0 +1,308 @@
+package gateway_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/internal/mock/library"
+	"github.com/dizzrt/ellie/internal/mock/ping"
+	"github.com/dizzrt/ellie/internal/mock/stream"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/dizzrt/ellie/transport/http/gateway"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+type pingServer struct {
+	ping.UnimplementedPingServiceServer
+
+	tenants chan string
+}
+
+func (s *pingServer) Ping(ctx context.Context, req *ping.PingRequest) (*ping.PingResponse, error) {
+	return &ping.PingResponse{Message: "pong"}, nil
+}
+
+func (s *pingServer) Hello(ctx context.Context, req *ping.HelloRequest) (*ping.HelloResponse, error) {
+	if s.tenants != nil {
+		md, _ := metadata.FromIncomingContext(ctx)
+		s.tenants <- strings.Join(md.Get("x-tenant"), ",")
+	}
+
+	return &ping.HelloResponse{
+		Message: fmt.Sprintf("hello %s, type is %s", req.GetName(), req.GetType()),
+	}, nil
+}
+
+type streamServer struct {
+	stream.UnimplementedStreamServiceServer
+}
+
+func (s *streamServer) Watch(req *stream.WatchRequest, ss grpc.ServerStreamingServer[stream.WatchEvent]) error {
+	if req.GetTopic() == "missing" {
+		return status.Error(codes.NotFound, "topic not found")
+	}
+
+	for i := int32(1); i <= req.GetCount(); i++ {
+		if err := ss.Send(&stream.WatchEvent{Topic: req.GetTopic(), Seq: i}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *streamServer) Echo(ss grpc.BidiStreamingServer[stream.WatchEvent, stream.WatchEvent]) error {
+	for {
+		event, err := ss.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = ss.Send(event); err != nil {
+			return err
+		}
+	}
+}
+
+type libraryServer struct {
+	library.UnimplementedLibraryServiceServer
+}
+
+func (s *libraryServer) GetBook(ctx context.Context, req *library.GetBookRequest) (*library.Book, error) {
+	return &library.Book{Name: req.GetName()}, nil
+}
+
+func (s *libraryServer) GetBookTitle(ctx context.Context, req *library.GetBookRequest) (*library.GetBookTitleResponse, error) {
+	return &library.GetBookTitleResponse{Title: "title of " + req.GetName()}, nil
+}
+
+func (s *libraryServer) UpdateBook(ctx context.Context, req *library.UpdateBookRequest) (*library.Book, error) {
+	return req.GetBook(), nil
+}
+
+func (s *libraryServer) MoveBook(ctx context.Context, req *library.MoveBookRequest) (*library.MoveBookResponse, error) {
+	return &library.MoveBookResponse{Book: &library.Book{Name: req.GetShelf().GetId() + "/" + req.GetBook()}}, nil
+}
+
+func newGRPCServer(ps *pingServer) *grpc.Server {
+	gs := grpc.NewServer()
+	ping.RegisterPingServiceServer(gs, ps)
+	stream.RegisterStreamServiceServer(gs, &streamServer{})
+	return gs
+}
+
+func serve(hs *http.Server, r *nhttp.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	hs.ServeHTTP(w, r)
+	return w
+}
+
+func TestRegisterServer(t *testing.T) {
+	ps := &pingServer{tenants: make(chan string, 1)}
+	gs := newGRPCServer(ps)
+	defer gs.Stop()
+
+	hs := http.NewServer()
+	assert.NoError(t, gateway.RegisterServer(hs, gs))
+
+	// the reply is rendered like the one of the generated handler
+	generated := http.NewServer()
+	ping.RegisterPingServiceHTTPServer(generated, ps)
+
+	w := serve(hs, httptest.NewRequest(nhttp.MethodGet, "/ping", nil))
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.JSONEq(t, serve(generated, httptest.NewRequest(nhttp.MethodGet, "/ping", nil)).Body.String(), w.Body.String())
+
+	r := httptest.NewRequest(nhttp.MethodPost, "/hello/ellie?type=ignored", strings.NewReader(`{"type":"gateway"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Grpc-Metadata-X-Tenant", "acme")
+	w = serve(hs, r)
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "hello ellie, type is gateway")
+	assert.Equal(t, "acme", <-ps.tenants)
+
+	// decoding errors are reported before the call
+	r = httptest.NewRequest(nhttp.MethodPost, "/hello/ellie", strings.NewReader(`{"type":`))
+	r.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, nhttp.StatusBadRequest, serve(hs, r).Code)
+
+	routes := make(map[string]string)
+	for _, ri := range hs.Routes() {
+		routes[ri.Method+" "+ri.Path] = ri.Operation
+	}
+
+	assert.Equal(t, "/PingService/Ping", routes["GET /ping"])
+	assert.Equal(t, "/PingService/Hello", routes["POST /hello/:name"])
+	assert.Equal(t, "/StreamService/Watch", routes["GET /watch/:topic"])
+	assert.NotContains(t, routes, "GET /echo")
+
+	err := gateway.RegisterServer(hs, gs)
+	assert.ErrorContains(t, err, "already registered")
+}
+
+func TestHTTPRule(t *testing.T) {
+	gs := grpc.NewServer()
+	library.RegisterLibraryServiceServer(gs, &libraryServer{})
+	defer gs.Stop()
+
+	hs := http.NewServer()
+	assert.NoError(t, gateway.RegisterServer(hs, gs))
+
+	w := serve(hs, httptest.NewRequest(nhttp.MethodGet, "/v1/shelves/1/books/2", nil))
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"data":{"name":"shelves/1/books/2"}`)
+
+	w = serve(hs, httptest.NewRequest(nhttp.MethodGet, "/v1/books/a/b", nil))
+	assert.Contains(t, w.Body.String(), `"data":{"name":"a/b"}`)
+
+	w = serve(hs, httptest.NewRequest(nhttp.MethodGet, "/v1/shelves/1/books/2/title", nil))
+	assert.Contains(t, w.Body.String(), `"data":"title of shelves/1/books/2"`)
+
+	r := httptest.NewRequest(nhttp.MethodPatch, "/v1/shelves/1/books/2", strings.NewReader(`{"title":"new"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w = serve(hs, r)
+	assert.Contains(t, w.Body.String(), `"title":"new"`)
+
+	r = httptest.NewRequest(nhttp.MethodPost, "/v1/shelves/1/books:move", strings.NewReader(`{"book":"b"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w = serve(hs, r)
+	assert.Contains(t, w.Body.String(), `"data":{"name":"1/b"}`)
+
+	// the primary binding is registered before the additional ones
+	hs = http.NewServer(http.UseRouter(http.ServeMuxRouter()))
+	assert.NoError(t, gateway.RegisterServer(hs, gs))
+
+	var paths []string
+	for _, ri := range hs.Routes() {
+		if ri.Operation == "/LibraryService/GetBook" {
+			paths = append(paths, ri.Path)
+		}
+	}
+
+	assert.Equal(t, []string{"/v1/shelves/:name.0/books/:name.1", "/v1/books/*name.0"}, paths)
+}
+
+func TestServerStream(t *testing.T) {
+	gs := newGRPCServer(&pingServer{})
+	defer gs.Stop()
+
+	hs := http.NewServer()
+	assert.NoError(t, gateway.RegisterServer(hs, gs))
+
+	r := httptest.NewRequest(nhttp.MethodGet, "/watch/news?count=2", nil)
+	r.Header.Set("Accept", http.NDJSONContentType)
+	w := serve(hs, r)
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+
+	var lines []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if assert.Len(t, lines, 2) {
+		assert.JSONEq(t, `{"topic":"news","seq":1}`, lines[0])
+		assert.JSONEq(t, `{"topic":"news","seq":2}`, lines[1])
+	}
+
+	// grpc errors are mapped like the ones returned by generated handlers
+	w = serve(hs, httptest.NewRequest(nhttp.MethodGet, "/watch/missing", nil))
+	assert.Equal(t, nhttp.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "topic not found")
+}
+
+func TestWebSocket(t *testing.T) {
+	gs := newGRPCServer(&pingServer{})
+	defer gs.Stop()
+
+	hs := http.NewServer()
+	assert.NoError(t, gateway.RegisterServer(hs, gs, gateway.WebSocket(true)))
+
+	ts := httptest.NewServer(hs)
+	defer ts.Close()
+
+	dialer := &websocket.Dialer{Subprotocols: []string{"json"}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/echo", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	for _, event := range []string{`{"topic":"a","seq":1}`, `{"topic":"b","seq":2}`} {
+		assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(event)))
+		_, data, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		assert.JSONEq(t, event, string(data))
+	}
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(http.WebSocketEndOfStream)))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), "%v", err)
+}
+
+func TestRegisterReflection(t *testing.T) {
+	gs := newGRPCServer(&pingServer{})
+	reflection.Register(gs)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	go func() {
+		_ = gs.Serve(lis)
+	}()
+	defer gs.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	services, err := gateway.Reflect(context.Background(), conn)
+	if assert.NoError(t, err) {
+		var names []string
+		for _, sd := range services {
+			names = append(names, string(sd.FullName()))
+		}
+
+		assert.ElementsMatch(t, []string{"ping.PingService", "stream.StreamService"}, names)
+	}
+
+	hs := http.NewServer()
+	assert.NoError(t, gateway.RegisterReflection(context.Background(), hs, conn))
+
+	w := serve(hs, httptest.NewRequest(nhttp.MethodGet, "/ping", nil))
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "pong")
+
+	r := httptest.NewRequest(nhttp.MethodPost, "/hello/ellie", strings.NewReader(`{"type":"reflection"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w = serve(hs, r)
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "hello ellie, type is reflection")
+
+	// methods without a rule are skipped by default
+	w = serve(hs, httptest.NewRequest(nhttp.MethodPost, "/ping.PingService/PingStream", nil))
+	assert.Equal(t, nhttp.StatusNotFound, w.Code)
+}
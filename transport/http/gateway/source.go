@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/dizzrt/ellie/errors"
+	thttp "github.com/dizzrt/ellie/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	reflectionServicePrefix = "grpc.reflection."
+
+	// pipeBufferSize is the buffer of the in-memory connection to the server.
+	pipeBufferSize = 1 << 20
+)
+
+// RegisterServer serves the services registered on gs, their descriptors are
+// looked up in the global registry. Calls are sent over an in-memory
+// connection served by gs, so interceptors and graceful stops apply as for
+// any other client. The connection is closed when gs stops.
+func RegisterServer(hs *thttp.Server, gs *grpc.Server, opts ...Option) error {
+	var services []protoreflect.ServiceDescriptor
+	for name := range gs.GetServiceInfo() {
+		if strings.HasPrefix(name, reflectionServicePrefix) {
+			continue
+		}
+
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return fmt.Errorf("gateway: descriptor of %s: %w", name, err)
+		}
+
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return fmt.Errorf("gateway: %s is not a service", name)
+		}
+
+		services = append(services, sd)
+	}
+
+	lis := bufconn.Listen(pipeBufferSize)
+	conn, err := grpc.NewClient("passthrough:///gateway",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = New(hs, conn, opts...).Register(services...); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	go func() {
+		_ = gs.Serve(lis)
+		_ = conn.Close()
+	}()
+
+	return nil
+}
+
+// RegisterReflection serves the services listed by the reflection service of
+// conn, e.g. an upstream registered with reflection.Register.
+func RegisterReflection(ctx context.Context, hs *thttp.Server, conn grpc.ClientConnInterface, opts ...Option) error {
+	services, err := Reflect(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return New(hs, conn, opts...).Register(services...)
+}
+
+// Reflect resolves the descriptors of the services exposed by the reflection
+// service of conn, the reflection service itself is skipped.
+func Reflect(ctx context.Context, conn grpc.ClientConnInterface) ([]protoreflect.ServiceDescriptor, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	call := func(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+
+		res, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+
+		if e := res.GetErrorResponse(); e != nil {
+			code := codes.Code(e.GetErrorCode())
+			return nil, errors.NewStandardError(&code, int(code), "REFLECTION_FAILED", e.GetErrorMessage())
+		}
+
+		return res, nil
+	}
+
+	res, err := call(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	addFiles := func(res *rpb.ServerReflectionResponse) error {
+		for _, raw := range res.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return err
+			}
+
+			files[fd.GetName()] = fd
+		}
+
+		return nil
+	}
+
+	var names []string
+	for _, s := range res.GetListServicesResponse().GetService() {
+		if strings.HasPrefix(s.GetName(), reflectionServicePrefix) {
+			continue
+		}
+
+		names = append(names, s.GetName())
+		res, err := call(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: s.GetName()},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err = addFiles(res); err != nil {
+			return nil, err
+		}
+	}
+
+	// servers usually send the dependencies along, fetch the missing ones
+	for missing := missingDependencies(files); len(missing) > 0; missing = missingDependencies(files) {
+		for _, name := range missing {
+			res, err := call(&rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if err = addFiles(res); err != nil {
+				return nil, err
+			}
+
+			if files[name] == nil {
+				return nil, fmt.Errorf("gateway: reflection didn't return %s", name)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range files {
+		set.File = append(set.File, fd)
+	}
+
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]protoreflect.ServiceDescriptor, 0, len(names))
+	for _, name := range names {
+		d, err := registry.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, err
+		}
+
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("gateway: %s is not a service", name)
+		}
+
+		services = append(services, sd)
+	}
+
+	return services, nil
+}
+
+func missingDependencies(files map[string]*descriptorpb.FileDescriptorProto) []string {
+	var missing []string
+	for _, fd := range files {
+		for _, dep := range fd.GetDependency() {
+			if files[dep] == nil {
+				missing = append(missing, dep)
+			}
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,99 @@
+package ginx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dizzrt/ellie/encoding"
+	"github.com/dizzrt/ellie/encoding/form"
+	"github.com/dizzrt/ellie/encoding/json"
+	thttp "github.com/dizzrt/ellie/transport/http"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func decodeProtoRequest(src *source, msg proto.Message, o *decodeOptions) error {
+	// the body is decoded first, codecs such as protobuf reset the message
+	if o.body != "" {
+		if err := decodeProtoBody(src, msg, o.body); err != nil {
+			return err
+		}
+	}
+
+	if !o.hasRule || o.body != "*" {
+		query := src.r.URL.Query()
+		if o.hasRule && o.body != "" {
+			for key := range query {
+				if key == o.body || strings.HasPrefix(key, o.body+".") || strings.HasPrefix(key, o.body+"[") {
+					delete(query, key)
+				}
+			}
+		}
+
+		if err := form.DecodeValues(msg, query); err != nil {
+			return err
+		}
+	}
+
+	return form.DecodeValues(msg, src.params)
+}
+
+func decodeProtoBody(src *source, msg proto.Message, field string) error {
+	rawBody, err := readBody(src)
+	if err != nil || len(rawBody) == 0 {
+		return err
+	}
+
+	if field == "*" {
+		return unmarshalProtoBody(src, rawBody, msg)
+	}
+
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(field))
+	if fd == nil {
+		return fmt.Errorf("body field %s not found in %s", field, m.Descriptor().FullName())
+	}
+
+	if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+		return unmarshalProtoBody(src, rawBody, m.Mutable(fd).Message().Interface())
+	}
+
+	// scalar, repeated and map fields are only supported for json bodies, the
+	// body is decoded as the value of the field and merged into msg
+	codec := encoding.GetCodecByContentType(src.contentType())
+	if codec == nil || codec.Name() != json.Name {
+		return thttp.ErrUnsupportedMediaType
+	}
+
+	tmp := m.New().Interface()
+	wrapped := make([]byte, 0, len(rawBody)+len(fd.JSONName())+5)
+	wrapped = append(wrapped, `{"`+fd.JSONName()+`":`...)
+	wrapped = append(wrapped, rawBody...)
+	wrapped = append(wrapped, '}')
+	if err := codec.Unmarshal(wrapped, tmp); err != nil {
+		return err
+	}
+
+	proto.Merge(msg, tmp)
+	return nil
+}
+
+func unmarshalProtoBody(src *source, rawBody []byte, msg proto.Message) error {
+	contentType := src.contentType()
+	if contentType == gin.MIMEMultipartPOSTForm {
+		values, err := parseMultipartBody(src, rawBody)
+		if err != nil {
+			return err
+		}
+
+		return form.DecodeValues(msg, values)
+	}
+
+	var codec encoding.Codec
+	if codec = encoding.GetCodecByContentType(contentType); codec == nil {
+		return thttp.ErrUnsupportedMediaType
+	}
+
+	return codec.Unmarshal(rawBody, msg)
+}
@@ -0,0 +1,123 @@
+package ginx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dizzrt/ellie/errors"
+	"github.com/dizzrt/ellie/internal/mock/binding"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeProtoQuery(t *testing.T) {
+	query := strings.Join([]string{
+		"name=ellie",
+		"id=9007199254740993",
+		"size=18446744073709551615",
+		"enabled=true",
+		"score=1.5",
+		"data=aGVsbG8",
+		"status=STATUS_ACTIVE",
+		"tags=a&tags=b",
+		"statuses=1&statuses=STATUS_DISABLED",
+		"labels[env]=dev",
+		"user.id=42&user.displayName=mike",
+		"keyword=go",
+		"createTime=2024-01-02T03:04:05.5Z",
+		"timeout=1m30s",
+		"nickname=ellie",
+		"limit=10",
+		"update_mask=name,user.displayName",
+		"extra=" + `{"k":"v"}`,
+		"cursor=abc",
+		"unknown=ignored",
+	}, "&")
+
+	r := httptest.NewRequest(http.MethodGet, "/bind?"+strings.ReplaceAll(query, `"`, "%22"), nil)
+	req := &binding.BindRequest{}
+	assert.NoError(t, DecodeRequest(newTestContext(r), req, Body("")))
+
+	assert.Equal(t, "ellie", req.GetName())
+	assert.Equal(t, int64(9007199254740993), req.GetId())
+	assert.Equal(t, uint64(18446744073709551615), req.GetSize())
+	assert.True(t, req.GetEnabled())
+	assert.Equal(t, 1.5, req.GetScore())
+	assert.Equal(t, "hello", string(req.GetData()))
+	assert.Equal(t, binding.Status_STATUS_ACTIVE, req.GetStatus())
+	assert.Equal(t, []string{"a", "b"}, req.GetTags())
+	assert.Equal(t, []binding.Status{binding.Status_STATUS_ACTIVE, binding.Status_STATUS_DISABLED}, req.GetStatuses())
+	assert.Equal(t, map[string]string{"env": "dev"}, req.GetLabels())
+	assert.Equal(t, int64(42), req.GetUser().GetId())
+	assert.Equal(t, "mike", req.GetUser().GetDisplayName())
+	assert.Equal(t, "go", req.GetKeyword())
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC), req.GetCreateTime().AsTime())
+	assert.Equal(t, 90*time.Second, req.GetTimeout().AsDuration())
+	assert.Equal(t, "ellie", req.GetNickname().GetValue())
+	assert.Equal(t, int64(10), req.GetLimit().GetValue())
+	assert.Equal(t, []string{"name", "user.display_name"}, req.GetUpdateMask().GetPaths())
+	assert.Equal(t, "v", req.GetExtra().GetFields()["k"].GetStringValue())
+	assert.Equal(t, "abc", req.GetCursor())
+}
+
+func TestDecodeProtoBodySemantics(t *testing.T) {
+	newRequest := func(target, body string) *gin.Context {
+		r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		c := newTestContext(r)
+		c.Params = gin.Params{{Key: "name", Value: "fromPath"}}
+		return c
+	}
+
+	// body "*" ignores query parameters, path parameters win over the body
+	req := &binding.BindRequest{}
+	c := newRequest("/bind?id=1&tags=q", `{"name":"fromBody","id":"2","createTime":"2024-01-02T03:04:05Z"}`)
+	assert.NoError(t, DecodeRequest(c, req, Body("*")))
+	assert.Equal(t, "fromPath", req.GetName())
+	assert.Equal(t, int64(2), req.GetId())
+	assert.Empty(t, req.GetTags())
+	assert.Equal(t, int64(1704164645), req.GetCreateTime().GetSeconds())
+
+	// body field binds the body to that field and the query to the rest
+	req = &binding.BindRequest{}
+	c = newRequest("/bind?id=1&user.id=3", `{"id":"7","displayName":"mike"}`)
+	assert.NoError(t, DecodeRequest(c, req, Body("user")))
+	assert.Equal(t, int64(1), req.GetId())
+	assert.Equal(t, int64(7), req.GetUser().GetId())
+	assert.Equal(t, "mike", req.GetUser().GetDisplayName())
+
+	// scalar body fields
+	req = &binding.BindRequest{}
+	c = newRequest("/bind", `["a","b"]`)
+	assert.NoError(t, DecodeRequest(c, req, Body("tags")))
+	assert.Equal(t, []string{"a", "b"}, req.GetTags())
+}
+
+func TestDecodeProtoFieldErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		field string
+	}{
+		{"id=abc", "id"},
+		{"status=STATUS_UNKNOWN", "status"},
+		{"user.id=x", "user.id"},
+		{"keyword=a&category=1", "keyword"},
+		{"createTime=yesterday", "createTime"},
+		{"labels=x", "labels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/bind?"+tt.query, nil)
+			err := DecodeRequest(newTestContext(r), &binding.BindRequest{}, Body(""))
+			se, ok := err.(*errors.StandardError)
+			if assert.True(t, ok, "expected *errors.StandardError, got %T", err) {
+				assert.Equal(t, tt.field, se.Metadata()["field"])
+				assert.Contains(t, se.Message(), tt.field)
+			}
+		})
+	}
+}
@@ -2,37 +2,170 @@
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
-	"github.com/bytedance/sonic"
+	"github.com/dizzrt/ellie/encoding"
+	"github.com/dizzrt/ellie/encoding/form"
+	"github.com/dizzrt/ellie/encoding/json"
+	thttp "github.com/dizzrt/ellie/transport/http"
 	"github.com/gin-gonic/gin"
 	"github.com/go-viper/mapstructure/v2"
+	"google.golang.org/protobuf/proto"
 )
 
-func DecodeRequest(ctx *gin.Context, req any) error {
+const defaultMultipartMemory = 32 << 20
+
+type filesKey struct{}
+
+// source is the request being decoded, decoding multipart bodies replaces r
+// with a copy carrying the files in its context.
+type source struct {
+	r      *http.Request
+	params url.Values
+}
+
+func (src *source) contentType() string {
+	contentType, _, _ := strings.Cut(src.r.Header.Get("Content-Type"), ";")
+	return strings.TrimSpace(contentType)
+}
+
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	body     string
+	hasRule  bool
+	pathVars []pathVar
+}
+
+type pathVar struct {
+	name    string
+	pattern string
+}
+
+// Body sets the google.api.http body of the route: "*" maps the whole body to
+// the request and ignores query parameters, a field name maps the body to that
+// top-level field and "" ignores the body. Without it the body, query and path
+// parameters are all bound.
+func Body(field string) DecodeOption {
+	return func(o *decodeOptions) {
+		o.body = field
+		o.hasRule = true
+	}
+}
+
+// PathVar binds a path variable whose pattern matches several segments, e.g.
+// PathVar("name", "shelves/*/books/*") for {name=shelves/*/books/*}. The
+// wildcards of the pattern are routed as the parameters name.0, name.1...,
+// they are joined with the literal segments into name.
+func PathVar(name, pattern string) DecodeOption {
+	return func(o *decodeOptions) {
+		o.pathVars = append(o.pathVars, pathVar{name: name, pattern: pattern})
+	}
+}
+
+// DecodeRequest binds path, query and body parameters into req. Bodies are
+// decoded by the codec registered for the request content type, failures are
+// reported as an InvalidArgument *errors.StandardError and unsupported
+// content types as thttp.ErrUnsupportedMediaType. Proto messages are bound by
+// reflection with protojson semantics, path parameters win over query
+// parameters and query parameters win over the body.
+func DecodeRequest(ctx *gin.Context, req any, opts ...DecodeOption) error {
+	params := make(url.Values, len(ctx.Params))
+	for _, param := range ctx.Params {
+		params.Set(param.Key, param.Value)
+	}
+
+	src := &source{r: ctx.Request, params: params}
+	err := decode(src, req, opts)
+	ctx.Request = src.r
+	return err
+}
+
+// DecodeHTTPRequest is DecodeRequest for handlers registered on any
+// thttp.Router, path parameters are taken from thttp.PathParams. The returned
+// request carries the multipart files in its context.
+func DecodeHTTPRequest(r *http.Request, req any, opts ...DecodeOption) (*http.Request, error) {
+	src := &source{r: r, params: thttp.PathParams(r)}
+	err := decode(src, req, opts)
+	return src.r, err
+}
+
+func decode(src *source, req any, opts []DecodeOption) error {
+	o := &decodeOptions{body: "*"}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if len(o.pathVars) > 0 {
+		src.params = joinPathVars(src.params, o.pathVars)
+	}
+
+	var err error
+	if msg, ok := req.(proto.Message); ok {
+		err = decodeProtoRequest(src, msg, o)
+	} else {
+		err = decodeRequest(src, req)
+	}
+
+	if err != nil {
+		return thttp.InvalidArgumentError(err)
+	}
+
+	return nil
+}
+
+// FilesFromContext returns the multipart file parts of a request decoded by
+// DecodeRequest, keyed by form field name.
+func FilesFromContext(ctx context.Context) map[string][]*multipart.FileHeader {
+	files, _ := ctx.Value(filesKey{}).(map[string][]*multipart.FileHeader)
+	return files
+}
+
+// FileFromContext returns the first file part of the given form field.
+func FileFromContext(ctx context.Context, name string) (*multipart.FileHeader, bool) {
+	files := FilesFromContext(ctx)[name]
+	if len(files) == 0 {
+		return nil, false
+	}
+
+	return files[0], true
+}
+
+func decodeRequest(src *source, req any) error {
 	inMap := make(map[string]any)
 
 	// path parameters. e.g. /user/:id
-	params := ctx.Params
-	for _, param := range params {
-		inMap[param.Key] = param.Value
+	for k, v := range src.params {
+		if len(v) > 0 {
+			inMap[k] = v[0]
+		}
 	}
 
 	// query parameters. e.g. /user?id=123
-	queries := ctx.Request.URL.Query()
+	queries := src.r.URL.Query()
 	for k, v := range queries {
 		if len(v) > 0 {
 			inMap[k] = v[0]
 		}
 	}
 
-	// body parameters
-	if err := parseBody(ctx, inMap); err != nil {
+	// body parameters, codecs without a map representation decode into req
+	// directly and the path and query parameters are bound on top
+	body, err := parseBody(src, req)
+	if err != nil {
 		return err
 	}
 
+	maps.Copy(inMap, body)
+
 	// decode to request struct
 	config := &mapstructure.DecoderConfig{
 		WeaklyTypedInput: true,
@@ -52,48 +185,130 @@ func DecodeRequest(ctx *gin.Context, req any) error {
 	return nil
 }
 
-func parseBody(ctx *gin.Context, inMap map[string]any) error {
-	contentType := ctx.ContentType()
-	if contentType == "" || ctx.Request.ContentLength <= 0 {
-		return nil
+// joinPathVars returns a copy of params where the wildcards of each variable
+// are replaced by the variable.
+func joinPathVars(params url.Values, vars []pathVar) url.Values {
+	joined := make(url.Values, len(params)+len(vars))
+	for k, v := range params {
+		joined[k] = v
+	}
+
+	for _, pv := range vars {
+		segments := strings.Split(pv.pattern, "/")
+		n := 0
+		for i, seg := range segments {
+			if seg != "*" && seg != "**" {
+				continue
+			}
+
+			key := pv.name + "." + strconv.Itoa(n)
+			n++
+
+			// catch-all values start with a slash
+			segments[i] = strings.TrimPrefix(joined.Get(key), "/")
+			joined.Del(key)
+		}
+
+		joined.Set(pv.name, strings.Join(segments, "/"))
+	}
+
+	return joined
+}
+
+// readBody reads and restores the request body, it returns nil when the
+// request has no body or no content type.
+func readBody(src *source) ([]byte, error) {
+	r := src.r
+	if src.contentType() == "" || r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
 	}
 
-	// read the body content
-	rawBody, err := io.ReadAll(ctx.Request.Body)
+	rawBody, err := io.ReadAll(r.Body)
 	if err != nil {
-		return err
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return nil, thttp.ErrRequestEntityTooLarge
+		}
+
+		return nil, err
 	}
 
-	if err = ctx.Request.Body.Close(); err != nil {
-		return err
+	if err = r.Body.Close(); err != nil {
+		return nil, err
 	}
 
 	// restore the io.ReadCloser to its original state
-	ctx.Request.Body = io.NopCloser(bytes.NewBuffer(rawBody))
+	r.Body = io.NopCloser(bytes.NewBuffer(rawBody))
+	return rawBody, nil
+}
 
-	// parse body according to content type
-	var body map[string]any
-	switch {
-	case strings.Contains(contentType, "application/json"):
-		fallthrough
-	default:
-		// try to parse as json
-		body, err = parseJsonBody(rawBody)
+func parseBody(src *source, req any) (map[string]any, error) {
+	rawBody, err := readBody(src)
+	if err != nil || len(rawBody) == 0 {
+		return nil, err
 	}
 
-	if err != nil {
-		return err
+	contentType := src.contentType()
+	if contentType == gin.MIMEMultipartPOSTForm {
+		values, err := parseMultipartBody(src, rawBody)
+		if err != nil {
+			return nil, err
+		}
+
+		return valuesToMap(values), nil
 	}
 
-	maps.Copy(inMap, body)
-	return nil
+	codec := encoding.GetCodecByContentType(contentType)
+	if codec == nil {
+		return nil, thttp.ErrUnsupportedMediaType
+	}
+
+	switch codec.Name() {
+	case json.Name:
+		var body map[string]any
+		if err := codec.Unmarshal(rawBody, &body); err != nil {
+			return nil, err
+		}
+
+		return body, nil
+	case form.Name:
+		values, err := url.ParseQuery(string(rawBody))
+		if err != nil {
+			return nil, err
+		}
+
+		return valuesToMap(values), nil
+	default:
+		return nil, codec.Unmarshal(rawBody, req)
+	}
 }
 
-func parseJsonBody(rawBody []byte) (map[string]any, error) {
-	var body map[string]any
-	if err := sonic.Unmarshal(rawBody, &body); err != nil {
+func parseMultipartBody(src *source, rawBody []byte) (url.Values, error) {
+	r := src.r
+	if err := r.ParseMultipartForm(defaultMultipartMemory); err != nil {
 		return nil, err
 	}
 
-	return body, nil
+	// restore the body again, ParseMultipartForm consumes it
+	r.Body = io.NopCloser(bytes.NewBuffer(rawBody))
+	if len(r.MultipartForm.File) > 0 {
+		src.r = r.WithContext(context.WithValue(r.Context(), filesKey{}, r.MultipartForm.File))
+	}
+
+	return r.MultipartForm.Value, nil
+}
+
+func valuesToMap(values map[string][]string) map[string]any {
+	body := make(map[string]any, len(values))
+	for k, v := range values {
+		switch len(v) {
+		case 0:
+		case 1:
+			body[k] = v[0]
+		default:
+			body[k] = v
+		}
+	}
+
+	return body
 }
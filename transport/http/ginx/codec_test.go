@@ -1,12 +1,21 @@
 package ginx
 
 import (
+	"bytes"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/dizzrt/ellie/errors"
+	thttp "github.com/dizzrt/ellie/transport/http"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
 )
 
 // Mock protobuf generated struct
@@ -35,3 +44,109 @@ func TestDecodeRequest(t *testing.T) {
 	// verify if sp_id is correctly bound
 	assert.Equal(t, uint32(10000), protoReq.SpId)
 }
+
+type UploadRequest struct {
+	Name  string   `json:"name,omitempty" xml:"name"`
+	Tags  []string `json:"tags,omitempty" xml:"tags"`
+	Count int32    `json:"count,omitempty" xml:"count"`
+}
+
+func newTestContext(r *http.Request) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = r
+	return c
+}
+
+func TestDecodeRequestBody(t *testing.T) {
+	// form
+	r := httptest.NewRequest(http.MethodPost, "/upload?count=3", strings.NewReader("name=ellie&tags=a&tags=b"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var formReq UploadRequest
+	assert.NoError(t, DecodeRequest(newTestContext(r), &formReq))
+	assert.Equal(t, UploadRequest{Name: "ellie", Tags: []string{"a", "b"}, Count: 3}, formReq)
+
+	// xml
+	r = httptest.NewRequest(http.MethodPost, "/upload?count=3", strings.NewReader("<UploadRequest><name>ellie</name></UploadRequest>"))
+	r.Header.Set("Content-Type", "application/xml")
+	var xmlReq UploadRequest
+	assert.NoError(t, DecodeRequest(newTestContext(r), &xmlReq))
+	assert.Equal(t, UploadRequest{Name: "ellie", Count: 3}, xmlReq)
+
+	// protobuf, path and query parameters are bound on top of the body
+	raw, err := proto.Marshal(&errors.ErrorCore{Code: 1, Reason: "REASON"})
+	assert.NoError(t, err)
+	r = httptest.NewRequest(http.MethodPost, "/upload?message=hello", bytes.NewReader(raw))
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	protoReq := &errors.ErrorCore{}
+	assert.NoError(t, DecodeRequest(newTestContext(r), protoReq))
+	assert.Equal(t, int32(1), protoReq.GetCode())
+	assert.Equal(t, "REASON", protoReq.GetReason())
+	assert.Equal(t, "hello", protoReq.GetMessage())
+}
+
+func TestDecodeRequestMultipart(t *testing.T) {
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	assert.NoError(t, mw.WriteField("name", "ellie"))
+	fw, err := mw.CreateFormFile("avatar", "avatar.png")
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte("png"))
+	assert.NoError(t, err)
+	assert.NoError(t, mw.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/upload", buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	c := newTestContext(r)
+
+	var req UploadRequest
+	assert.NoError(t, DecodeRequest(c, &req))
+	assert.Equal(t, "ellie", req.Name)
+
+	fh, ok := FileFromContext(c.Request.Context(), "avatar")
+	if assert.True(t, ok) {
+		assert.Equal(t, "avatar.png", fh.Filename)
+		f, err := fh.Open()
+		assert.NoError(t, err)
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, "png", string(data))
+	}
+}
+
+func TestDecodeRequestErrors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("a,b"))
+	r.Header.Set("Content-Type", "text/csv")
+	err := DecodeRequest(newTestContext(r), &UploadRequest{})
+	assert.True(t, errors.Is(err, thttp.ErrUnsupportedMediaType))
+	assert.Equal(t, http.StatusUnsupportedMediaType, thttp.HTTPStatusCodeFromError(err))
+
+	r = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("{"))
+	r.Header.Set("Content-Type", "application/json")
+	err = DecodeRequest(newTestContext(r), &UploadRequest{})
+	se, ok := err.(*errors.StandardError)
+	if assert.True(t, ok, "expected *errors.StandardError, got %T", err) {
+		assert.Equal(t, codes.InvalidArgument, *se.Status())
+	}
+	assert.Equal(t, http.StatusBadRequest, thttp.HTTPStatusCodeFromError(err))
+}
+
+func TestDecodeHTTPRequestPathVar(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/shelves/1/books/2", nil)
+	r = thttp.WithPathParams(r, url.Values{"name.0": {"1"}, "name.1": {"2"}})
+
+	var req struct {
+		Name string `json:"name"`
+	}
+
+	_, err := DecodeHTTPRequest(r, &req, PathVar("name", "shelves/*/books/*"))
+	assert.NoError(t, err)
+	assert.Equal(t, "shelves/1/books/2", req.Name)
+
+	r = thttp.WithPathParams(r, url.Values{"name.0": {"/a/b"}})
+	_, err = DecodeHTTPRequest(r, &req, PathVar("name", "**"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a/b", req.Name)
+}
@@ -0,0 +1,706 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dizzrt/ellie/encoding"
+	"github.com/dizzrt/ellie/encoding/json"
+	eproto "github.com/dizzrt/ellie/encoding/proto"
+	"github.com/dizzrt/ellie/errors"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+	connectStreamPrefix    = "application/connect+"
+	connectProtocolHeader  = "Connect-Protocol-Version"
+
+	grpcFrameHeaderLength = 5
+	frameCompressed       = 0x01
+	connectEndStreamFlag  = 0x02
+	grpcWebTrailerFlag    = 0x80
+
+	maxBridgeMessageSize = 4 << 20
+)
+
+var (
+	// GRPCWebAllowedHeaders are the request headers sent by gRPC-Web and
+	// Connect clients, they must be allowed by CORS.
+	GRPCWebAllowedHeaders = []string{
+		"Content-Type", "X-Grpc-Web", "X-User-Agent", "Grpc-Timeout",
+		connectProtocolHeader, "Connect-Timeout-Ms", "Connect-Content-Encoding", "Connect-Accept-Encoding",
+	}
+
+	// GRPCWebExposedHeaders are the response headers read by gRPC-Web and
+	// Connect clients, they must be exposed by CORS.
+	GRPCWebExposedHeaders = []string{
+		"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin", "Grpc-Encoding",
+		"Connect-Content-Encoding", "Content-Encoding",
+	}
+)
+
+type grpcBridgeProtocol int
+
+const (
+	grpcWebProtocol grpcBridgeProtocol = iota
+	grpcWebTextProtocol
+	connectUnaryProtocol
+	connectStreamProtocol
+)
+
+var connectCodes = map[codes.Code]string{
+	codes.Canceled:           "canceled",
+	codes.Unknown:            "unknown",
+	codes.InvalidArgument:    "invalid_argument",
+	codes.DeadlineExceeded:   "deadline_exceeded",
+	codes.NotFound:           "not_found",
+	codes.AlreadyExists:      "already_exists",
+	codes.PermissionDenied:   "permission_denied",
+	codes.ResourceExhausted:  "resource_exhausted",
+	codes.FailedPrecondition: "failed_precondition",
+	codes.Aborted:            "aborted",
+	codes.OutOfRange:         "out_of_range",
+	codes.Unimplemented:      "unimplemented",
+	codes.Internal:           "internal",
+	codes.Unavailable:        "unavailable",
+	codes.DataLoss:           "data_loss",
+	codes.Unauthenticated:    "unauthenticated",
+}
+
+type connectError struct {
+	Code    string               `json:"code"`
+	Message string               `json:"message,omitempty"`
+	Details []connectErrorDetail `json:"details,omitempty"`
+}
+
+type connectErrorDetail struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type connectEndStream struct {
+	Error    *connectError       `json:"error,omitempty"`
+	Metadata map[string][]string `json:"metadata,omitempty"`
+}
+
+// grpcServiceInfo is implemented by *grpc.Server, it lists the methods the
+// mounted server serves.
+type grpcServiceInfo interface {
+	GetServiceInfo() map[string]grpc.ServiceInfo
+}
+
+// grpcBridge translates a gRPC-Web or Connect request into a grpc call served
+// by the mounted grpc server and translates the response back.
+type grpcBridge struct {
+	protocol    grpcBridgeProtocol
+	codec       string
+	contentType string
+	input       protoreflect.MessageType
+	output      protoreflect.MessageType
+}
+
+func (s *Server) grpcBridgeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := newGRPCBridge(r)
+		if !ok || !s.servesGRPCMethod(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		b.serve(s.grpcService, w, r)
+	})
+}
+
+// servesGRPCMethod reports whether path is a /package.Service/Method of the
+// mounted server. Servers that don't list their services, see
+// grpcServiceInfo, serve the methods of the global proto registry.
+func (s *Server) servesGRPCMethod(path string) bool {
+	service, method, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return false
+	}
+
+	gs, ok := s.grpcService.(grpcServiceInfo)
+	if !ok {
+		return findMethod(path) != nil
+	}
+
+	info, ok := gs.GetServiceInfo()[service]
+	if !ok {
+		return false
+	}
+
+	for _, m := range info.Methods {
+		if m.Name == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newGRPCBridge(r *http.Request) (*grpcBridge, bool) {
+	if r.Method != http.MethodPost {
+		return nil, false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, false
+	}
+
+	b := &grpcBridge{contentType: mediaType}
+	switch {
+	case strings.HasPrefix(mediaType, grpcWebTextContentType):
+		b.protocol = grpcWebTextProtocol
+		b.codec = strings.TrimPrefix(strings.TrimPrefix(mediaType, grpcWebTextContentType), "+")
+	case strings.HasPrefix(mediaType, grpcWebContentType):
+		b.protocol = grpcWebProtocol
+		b.codec = strings.TrimPrefix(strings.TrimPrefix(mediaType, grpcWebContentType), "+")
+	case strings.HasPrefix(mediaType, connectStreamPrefix):
+		b.protocol = connectStreamProtocol
+		b.codec = strings.TrimPrefix(mediaType, connectStreamPrefix)
+	case r.Header.Get(connectProtocolHeader) != "" && strings.HasPrefix(mediaType, "application/"):
+		b.protocol = connectUnaryProtocol
+		b.codec = strings.TrimPrefix(mediaType, "application/")
+	default:
+		return nil, false
+	}
+
+	if b.codec == "" {
+		b.codec = eproto.Name
+	}
+
+	return b, true
+}
+
+func (b *grpcBridge) serve(srv http.Handler, w http.ResponseWriter, r *http.Request) {
+	bw := &grpcBridgeWriter{bridge: b, w: w, header: make(http.Header)}
+	req, err := b.grpcRequest(r)
+	if err != nil {
+		bw.fail(err)
+		return
+	}
+
+	srv.ServeHTTP(bw, req)
+	_ = req.Body.Close()
+	bw.finish()
+}
+
+func (b *grpcBridge) framed() bool {
+	return b.protocol != connectUnaryProtocol
+}
+
+// grpcRequest rewrites r into a grpc request over HTTP/2 with proto messages.
+func (b *grpcBridge) grpcRequest(r *http.Request) (*http.Request, error) {
+	if b.codec != eproto.Name && b.codec != json.Name {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	if b.codec == json.Name {
+		var err error
+		if b.input, b.output, err = lookupMethodTypes(r.URL.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	req := r.Clone(r.Context())
+	req.Method = http.MethodPost
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+	req.ContentLength = -1
+
+	h := req.Header
+	h.Set("Content-Type", "application/grpc+proto")
+	h.Del("Content-Length")
+	h.Del("X-Grpc-Web")
+	h.Del(connectProtocolHeader)
+
+	if b.protocol == connectUnaryProtocol || b.protocol == connectStreamProtocol {
+		moveHeader(h, "Connect-Content-Encoding", "Grpc-Encoding")
+		moveHeader(h, "Connect-Accept-Encoding", "Grpc-Accept-Encoding")
+		if err := connectTimeout(h); err != nil {
+			return nil, err
+		}
+	}
+
+	var body io.Reader = r.Body
+	if b.protocol == grpcWebTextProtocol {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	if b.protocol == connectUnaryProtocol {
+		flags := byte(0)
+		if ce := h.Get("Content-Encoding"); ce != "" && ce != "identity" {
+			h.Set("Grpc-Encoding", ce)
+			flags = frameCompressed
+		}
+
+		h.Del("Content-Encoding")
+		h.Del("Accept-Encoding")
+
+		data, err := io.ReadAll(io.LimitReader(body, maxBridgeMessageSize+1))
+		if err != nil {
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				return nil, ErrRequestEntityTooLarge
+			}
+
+			return nil, err
+		}
+
+		if len(data) > maxBridgeMessageSize {
+			return nil, errMessageTooLarge()
+		}
+
+		if b.codec == json.Name {
+			if flags != 0 {
+				return nil, errCompressedJSON()
+			}
+
+			if data, err = transcodeMessage(b.input, data, true); err != nil {
+				return nil, err
+			}
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(grpcFrame(flags, data)))
+		return req, nil
+	}
+
+	if b.codec == json.Name {
+		if ge := h.Get("Grpc-Encoding"); ge != "" && ge != "identity" {
+			return nil, errCompressedJSON()
+		}
+
+		req.Body = transcodeRequestStream(b.input, body)
+		return req, nil
+	}
+
+	req.Body = io.NopCloser(body)
+	return req, nil
+}
+
+func moveHeader(h http.Header, from, to string) {
+	if v := h.Values(from); len(v) > 0 {
+		h[to] = v
+		h.Del(from)
+	}
+}
+
+// connectTimeout converts Connect-Timeout-Ms to grpc-timeout, which allows at
+// most 8 digits.
+func connectTimeout(h http.Header) error {
+	v := h.Get("Connect-Timeout-Ms")
+	if v == "" {
+		return nil
+	}
+
+	h.Del("Connect-Timeout-Ms")
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || ms < 0 {
+		return InvalidArgumentError(fmt.Errorf("invalid Connect-Timeout-Ms %q", v))
+	}
+
+	if ms < 1e8 {
+		h.Set("Grpc-Timeout", strconv.FormatInt(ms, 10)+"m")
+	} else {
+		h.Set("Grpc-Timeout", strconv.FormatInt(ms/1000, 10)+"S")
+	}
+
+	return nil
+}
+
+func lookupMethodTypes(path string) (protoreflect.MessageType, protoreflect.MessageType, error) {
+	md := findMethod(path)
+	if md == nil {
+		return nil, nil, errUnknownMethod(path)
+	}
+
+	input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return input, output, nil
+}
+
+// findMethod returns the descriptor of the /package.Service/Method path in
+// the global proto registry, or nil.
+func findMethod(path string) protoreflect.MethodDescriptor {
+	service, method, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil
+	}
+
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+
+	return sd.Methods().ByName(protoreflect.Name(method))
+}
+
+func errUnknownMethod(path string) error {
+	code := codes.Unimplemented
+	return errors.NewStandardErrorf(&code, int(code), "UNKNOWN_METHOD", "unknown method %s", path)
+}
+
+func errCompressedJSON() error {
+	code := codes.Unimplemented
+	return errors.NewStandardError(&code, int(code), "UNSUPPORTED_ENCODING", "compressed json messages are not supported")
+}
+
+func errMessageTooLarge() error {
+	code := codes.ResourceExhausted
+	return errors.NewStandardErrorf(&code, int(code), "MESSAGE_TOO_LARGE", "message larger than %d bytes", maxBridgeMessageSize)
+}
+
+// transcodeMessage converts a message between json and the proto wire format.
+func transcodeMessage(mt protoreflect.MessageType, data []byte, toProto bool) ([]byte, error) {
+	msg := mt.New().Interface()
+	codec := encoding.GetCodec(json.Name)
+	if toProto {
+		if err := codec.Unmarshal(data, msg); err != nil {
+			return nil, InvalidArgumentError(err)
+		}
+
+		return proto.Marshal(msg)
+	}
+
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+
+	return codec.Marshal(msg)
+}
+
+// transcodeRequestStream converts the json frames of body to proto frames.
+func transcodeRequestStream(mt protoreflect.MessageType, body io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			flags, data, err := readFrame(body)
+			if err == io.EOF {
+				_ = pw.Close()
+				return
+			}
+
+			if err == nil {
+				data, err = transcodeMessage(mt, data, true)
+			}
+
+			if err == nil {
+				_, err = pw.Write(grpcFrame(flags, data))
+			}
+
+			if err != nil {
+				_ = pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+
+	return pr
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, grpcFrameHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	n := binary.BigEndian.Uint32(header[1:])
+	if n > maxBridgeMessageSize {
+		return 0, nil, errMessageTooLarge()
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+
+	return header[0], data, nil
+}
+
+func grpcFrame(flags byte, data []byte) []byte {
+	frame := make([]byte, grpcFrameHeaderLength+len(data))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	copy(frame[grpcFrameHeaderLength:], data)
+	return frame
+}
+
+// grpcBridgeWriter receives the grpc response, the messages of framed
+// protocols are forwarded as they are flushed and the status is written as a
+// trailer frame or an end of stream message. Unary Connect responses are
+// buffered until the status is known.
+type grpcBridgeWriter struct {
+	bridge *grpcBridge
+	w      http.ResponseWriter
+	header http.Header
+
+	wroteHeader bool
+	buf         []byte
+	message     []byte
+	compressed  bool
+	err         error
+}
+
+func (bw *grpcBridgeWriter) Header() http.Header {
+	return bw.header
+}
+
+// WriteHeader is a no-op, the status is derived from grpc-status.
+func (bw *grpcBridgeWriter) WriteHeader(int) {}
+
+func (bw *grpcBridgeWriter) Write(p []byte) (int, error) {
+	if bw.err != nil {
+		return 0, bw.err
+	}
+
+	bw.buf = append(bw.buf, p...)
+	for len(bw.buf) >= grpcFrameHeaderLength {
+		n := grpcFrameHeaderLength + int(binary.BigEndian.Uint32(bw.buf[1:grpcFrameHeaderLength]))
+		if len(bw.buf) < n {
+			break
+		}
+
+		if err := bw.writeMessage(bw.buf[0], bw.buf[grpcFrameHeaderLength:n]); err != nil {
+			bw.err = err
+			return 0, err
+		}
+
+		bw.buf = bw.buf[n:]
+	}
+
+	return len(p), nil
+}
+
+func (bw *grpcBridgeWriter) Flush() {
+	if !bw.bridge.framed() {
+		return
+	}
+
+	bw.writeHeader(http.StatusOK, bw.bridge.contentType)
+	if f, ok := bw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (bw *grpcBridgeWriter) writeMessage(flags byte, data []byte) error {
+	if !bw.bridge.framed() {
+		bw.message = bytes.Clone(data)
+		bw.compressed = flags&frameCompressed != 0
+		return nil
+	}
+
+	if bw.bridge.codec == json.Name {
+		if flags&frameCompressed != 0 {
+			return errCompressedJSON()
+		}
+
+		var err error
+		if data, err = transcodeMessage(bw.bridge.output, data, false); err != nil {
+			return err
+		}
+	}
+
+	bw.writeHeader(http.StatusOK, bw.bridge.contentType)
+	return bw.writeFrame(flags, data)
+}
+
+func (bw *grpcBridgeWriter) writeFrame(flags byte, data []byte) error {
+	frame := grpcFrame(flags, data)
+	if bw.bridge.protocol == grpcWebTextProtocol {
+		frame = []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+
+	_, err := bw.w.Write(frame)
+	return err
+}
+
+func (bw *grpcBridgeWriter) writeHeader(code int, contentType string) {
+	if bw.wroteHeader {
+		return
+	}
+
+	bw.wroteHeader = true
+	h := bw.w.Header()
+	for k, vs := range bw.header {
+		switch {
+		case len(vs) == 0, k == "Trailer", k == "Content-Type", strings.HasPrefix(k, http.TrailerPrefix):
+		case k == "Grpc-Status", k == "Grpc-Message", k == "Grpc-Status-Details-Bin":
+		case k == "Grpc-Encoding" && bw.bridge.protocol == connectStreamProtocol:
+			h["Connect-Content-Encoding"] = vs
+		case k == "Grpc-Encoding" && bw.bridge.protocol == connectUnaryProtocol:
+			if bw.compressed && code == http.StatusOK {
+				h["Content-Encoding"] = vs
+			}
+		default:
+			h[k] = vs
+		}
+	}
+
+	h.Set("Content-Type", contentType)
+	bw.w.WriteHeader(code)
+}
+
+// fail reports err without calling the grpc server.
+func (bw *grpcBridgeWriter) fail(err error) {
+	bw.header.Set("Grpc-Status", strconv.Itoa(int(errors.StatusCodeFromError(err))))
+	bw.header.Set("Grpc-Message", url.PathEscape(errorMessage(err)))
+	bw.finish()
+}
+
+func (bw *grpcBridgeWriter) finish() {
+	st := bw.status()
+	trailers := bw.trailers()
+
+	switch bw.bridge.protocol {
+	case grpcWebProtocol, grpcWebTextProtocol:
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "grpc-status: %d\r\n", st.Code())
+		for _, k := range []string{"Grpc-Message", "Grpc-Status-Details-Bin"} {
+			if v := bw.header.Get(k); v != "" {
+				fmt.Fprintf(&buf, "%s: %s\r\n", strings.ToLower(k), v)
+			}
+		}
+
+		for k, vs := range trailers {
+			for _, v := range vs {
+				fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
+			}
+		}
+
+		bw.writeHeader(http.StatusOK, bw.bridge.contentType)
+		_ = bw.writeFrame(grpcWebTrailerFlag, buf.Bytes())
+	case connectStreamProtocol:
+		end := &connectEndStream{Metadata: trailers}
+		if st.Code() != codes.OK {
+			end.Error = newConnectError(st)
+		}
+
+		data, _ := encoding.GetCodec(json.Name).Marshal(end)
+		bw.writeHeader(http.StatusOK, bw.bridge.contentType)
+		_ = bw.writeFrame(connectEndStreamFlag, data)
+	case connectUnaryProtocol:
+		h := bw.w.Header()
+		for k, vs := range trailers {
+			h["Trailer-"+k] = vs
+		}
+
+		body := bw.message
+		if st.Code() == codes.OK && bw.bridge.codec == json.Name {
+			var err error
+			if body, err = transcodeMessage(bw.bridge.output, body, false); err != nil {
+				st = status.New(codes.Internal, err.Error())
+			}
+		}
+
+		if st.Code() == codes.OK {
+			bw.writeHeader(http.StatusOK, bw.bridge.contentType)
+			_, _ = bw.w.Write(body)
+			return
+		}
+
+		data, _ := encoding.GetCodec(json.Name).Marshal(newConnectError(st))
+		bw.writeHeader(HTTPStatusFromGRPCCode(st.Code()), "application/json")
+		_, _ = bw.w.Write(data)
+		return
+	}
+
+	if f, ok := bw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// status reads the grpc status written by the grpc server.
+func (bw *grpcBridgeWriter) status() *status.Status {
+	v := bw.header.Get("Grpc-Status")
+	if v == "" {
+		return status.New(codes.Unknown, "missing grpc status")
+	}
+
+	code, err := strconv.Atoi(v)
+	if err != nil {
+		return status.New(codes.Unknown, "malformed grpc status "+v)
+	}
+
+	message := bw.header.Get("Grpc-Message")
+	if m, err := url.PathUnescape(message); err == nil {
+		message = m
+	}
+
+	if bin := bw.header.Get("Grpc-Status-Details-Bin"); bin != "" {
+		if data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(bin, "=")); err == nil {
+			sp := &spb.Status{}
+			if proto.Unmarshal(data, sp) == nil {
+				return status.FromProto(sp)
+			}
+		}
+	}
+
+	return status.New(codes.Code(code), message)
+}
+
+// trailers returns the custom trailers with lower case keys.
+func (bw *grpcBridgeWriter) trailers() map[string][]string {
+	var trailers map[string][]string
+	for k, vs := range bw.header {
+		if name, ok := strings.CutPrefix(k, http.TrailerPrefix); ok {
+			if trailers == nil {
+				trailers = make(map[string][]string)
+			}
+
+			trailers[strings.ToLower(name)] = vs
+		}
+	}
+
+	return trailers
+}
+
+func newConnectError(st *status.Status) *connectError {
+	ce := &connectError{
+		Code:    connectCodes[st.Code()],
+		Message: st.Message(),
+	}
+
+	if ce.Code == "" {
+		ce.Code = connectCodes[codes.Unknown]
+	}
+
+	for _, d := range st.Proto().GetDetails() {
+		typ := d.GetTypeUrl()
+		if i := strings.LastIndex(typ, "/"); i >= 0 {
+			typ = typ[i+1:]
+		}
+
+		ce.Details = append(ce.Details, connectErrorDetail{
+			Type:  typ,
+			Value: base64.RawStdEncoding.EncodeToString(d.GetValue()),
+		})
+	}
+
+	return ce
+}
@@ -0,0 +1,174 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/internal/mock/ping"
+	"github.com/dizzrt/ellie/internal/mock/stream"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+type frame struct {
+	flags byte
+	data  []byte
+}
+
+func encodeFrame(flags byte, data []byte) []byte {
+	buf := make([]byte, 5+len(data))
+	buf[0] = flags
+	binary.BigEndian.PutUint32(buf[1:], uint32(len(data)))
+	copy(buf[5:], data)
+	return buf
+}
+
+func decodeFrames(t *testing.T, body []byte) []frame {
+	var frames []frame
+	for len(body) > 0 {
+		if !assert.GreaterOrEqual(t, len(body), 5) {
+			return nil
+		}
+
+		n := 5 + int(binary.BigEndian.Uint32(body[1:5]))
+		frames = append(frames, frame{flags: body[0], data: body[5:n]})
+		body = body[n:]
+	}
+
+	return frames
+}
+
+func TestGRPCWeb(t *testing.T) {
+	gs := grpc.NewServer()
+	ping.RegisterPingServiceServer(gs, &pingServer{})
+	stream.RegisterStreamServiceServer(gs, &streamServer{})
+
+	srv := http.NewServer(http.MountGRPC(gs))
+	ping.RegisterPingServiceHTTPServer(srv, &pingServer{})
+
+	t.Run("grpc-web", func(t *testing.T) {
+		req, err := proto.Marshal(&ping.HelloRequest{Name: "ellie", Type: "web"})
+		assert.NoError(t, err)
+
+		w := serve(srv, nhttp.MethodPost, "/ping.PingService/Hello", bytes.NewReader(encodeFrame(0, req)), "Content-Type", "application/grpc-web+proto")
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Equal(t, "application/grpc-web+proto", w.Header().Get("Content-Type"))
+
+		frames := decodeFrames(t, w.Body.Bytes())
+		if assert.Len(t, frames, 2) {
+			reply := &ping.HelloResponse{}
+			assert.NoError(t, proto.Unmarshal(frames[0].data, reply))
+			assert.Equal(t, "hello ellie, type is web", reply.GetMessage())
+
+			assert.Equal(t, byte(0x80), frames[1].flags)
+			assert.Contains(t, string(frames[1].data), "grpc-status: 0\r\n")
+		}
+
+		// text encoding, every frame is a padded base64 chunk
+		body := base64.StdEncoding.EncodeToString(encodeFrame(0, req))
+		w = serve(srv, nhttp.MethodPost, "/ping.PingService/Hello", strings.NewReader(body), "Content-Type", "application/grpc-web-text")
+		assert.Equal(t, "application/grpc-web-text", w.Header().Get("Content-Type"))
+
+		var raw []byte
+		for _, chunk := range regexp.MustCompile(`[^=]+=*`).FindAllString(w.Body.String(), -1) {
+			data, err := base64.StdEncoding.DecodeString(chunk)
+			assert.NoError(t, err)
+			raw = append(raw, data...)
+		}
+
+		frames = decodeFrames(t, raw)
+		if assert.Len(t, frames, 2) {
+			assert.Contains(t, string(frames[1].data), "grpc-status: 0\r\n")
+		}
+
+		// errors are sent as trailers in the body
+		w = serve(srv, nhttp.MethodPost, "/ping.PingService/PingStream", bytes.NewReader(encodeFrame(0, nil)), "Content-Type", "application/grpc-web")
+		frames = decodeFrames(t, w.Body.Bytes())
+		if assert.Len(t, frames, 1) {
+			assert.Contains(t, string(frames[0].data), "grpc-status: 12\r\n")
+		}
+
+		// other requests are served by the http routes
+		w = serve(srv, nhttp.MethodGet, "/ping", nil)
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "pong")
+	})
+
+	t.Run("connect unary", func(t *testing.T) {
+		connect := func(contentType string) []string {
+			return []string{"Content-Type", contentType, "Connect-Protocol-Version", "1", "Connect-Timeout-Ms", "5000"}
+		}
+
+		w := serve(srv, nhttp.MethodPost, "/ping.PingService/Hello", strings.NewReader(`{"name":"ellie","type":"connect"}`), connect("application/json")...)
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"message":"hello ellie, type is connect"}`, w.Body.String())
+
+		req, err := proto.Marshal(&ping.HelloRequest{Name: "ellie"})
+		assert.NoError(t, err)
+		w = serve(srv, nhttp.MethodPost, "/ping.PingService/Hello", bytes.NewReader(req), connect("application/proto")...)
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		reply := &ping.HelloResponse{}
+		assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), reply))
+		assert.Equal(t, "hello ellie, type is ", reply.GetMessage())
+
+		// errors use the http status of the grpc code and a json body
+		w = serve(srv, nhttp.MethodPost, "/ping.PingService/PingStream", nil, connect("application/proto")...)
+		assert.Equal(t, nhttp.StatusNotImplemented, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var ce map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &ce))
+		assert.Equal(t, "unimplemented", ce["code"])
+
+		w = serve(srv, nhttp.MethodPost, "/ping.PingService/Hello", strings.NewReader(`{"name":`), connect("application/json")...)
+		assert.Equal(t, nhttp.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"invalid_argument"`)
+
+		w = serve(srv, nhttp.MethodPost, "/ping.PingService/Hello", nil, connect("application/xml")...)
+		assert.Equal(t, nhttp.StatusBadRequest, w.Code)
+
+		// only the methods of the grpc server are bridged, other requests are
+		// served by the http routes
+		w = serve(srv, nhttp.MethodPost, "/hello/ellie", strings.NewReader(`{"type":"http"}`), connect("application/json")...)
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "hello ellie, type is http")
+
+		w = serve(srv, nhttp.MethodPost, "/ping.PingService/Nope", nil, connect("application/proto")...)
+		assert.Equal(t, nhttp.StatusNotFound, w.Code)
+
+		w = serve(srv, nhttp.MethodPost, "/ping.Ping_v2/Ping", strings.NewReader(`{}`), connect("application/json")...)
+		assert.Equal(t, nhttp.StatusNotFound, w.Code)
+	})
+
+	t.Run("connect stream", func(t *testing.T) {
+		w := serve(srv, nhttp.MethodPost, "/stream.StreamService/Watch", bytes.NewReader(encodeFrame(0, []byte(`{"topic":"news","count":2}`))), "Content-Type", "application/connect+json")
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Equal(t, "application/connect+json", w.Header().Get("Content-Type"))
+
+		frames := decodeFrames(t, w.Body.Bytes())
+		if assert.Len(t, frames, 3) {
+			assert.JSONEq(t, `{"topic":"news","seq":1}`, string(frames[0].data))
+			assert.JSONEq(t, `{"topic":"news","seq":2}`, string(frames[1].data))
+			assert.Equal(t, byte(0x02), frames[2].flags)
+			assert.JSONEq(t, `{}`, string(frames[2].data))
+		}
+
+		w = serve(srv, nhttp.MethodPost, "/stream.StreamService/Watch", bytes.NewReader(encodeFrame(0, []byte(`{"topic":"missing"}`))), "Content-Type", "application/connect+json")
+		frames = decodeFrames(t, w.Body.Bytes())
+		if assert.Len(t, frames, 1) {
+			assert.Equal(t, byte(0x02), frames[0].flags)
+			assert.True(t, strings.Contains(string(frames[0].data), `"code":"not_found"`), string(frames[0].data))
+			assert.Contains(t, string(frames[0].data), "topic not found")
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package http_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/internal/mock/library"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+type libraryServer struct{}
+
+func (s *libraryServer) GetBook(ctx context.Context, req *library.GetBookRequest) (*library.Book, error) {
+	return &library.Book{Name: req.GetName(), Title: "title of " + req.GetName()}, nil
+}
+
+func (s *libraryServer) GetBookTitle(ctx context.Context, req *library.GetBookRequest) (*library.GetBookTitleResponse, error) {
+	return &library.GetBookTitleResponse{Title: "title of " + req.GetName()}, nil
+}
+
+func (s *libraryServer) UpdateBook(ctx context.Context, req *library.UpdateBookRequest) (*library.Book, error) {
+	return req.GetBook(), nil
+}
+
+func (s *libraryServer) MoveBook(ctx context.Context, req *library.MoveBookRequest) (*library.MoveBookResponse, error) {
+	return &library.MoveBookResponse{
+		Book:     &library.Book{Name: "shelves/" + req.GetShelf().GetId() + "/" + req.GetBook()},
+		Position: 1,
+	}, nil
+}
+
+func TestHTTPRuleSemantics(t *testing.T) {
+	for name, router := range map[string]http.Router{"gin": http.GinRouter(nil), "mux": http.ServeMuxRouter()} {
+		t.Run(name, func(t *testing.T) {
+			srv := http.NewServer(http.UseRouter(router))
+			library.RegisterLibraryServiceHTTPServer(srv, &libraryServer{})
+
+			serve := func(method, path, body string) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(method, path, strings.NewReader(body))
+				if body != "" {
+					r.Header.Set("Content-Type", "application/json")
+				}
+
+				w := httptest.NewRecorder()
+				srv.ServeHTTP(w, r)
+				return w
+			}
+
+			// multi-segment variables are joined back into one field
+			w := serve(nhttp.MethodGet, "/v1/shelves/1/books/2", "")
+			assert.Equal(t, nhttp.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), `"name":"shelves/1/books/2"`)
+
+			w = serve(nhttp.MethodGet, "/v1/books/a/b/c", "")
+			assert.Equal(t, nhttp.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), `"name":"a/b/c"`)
+
+			// response_body renders a single field
+			w = serve(nhttp.MethodGet, "/v1/shelves/1/books/2/title", "")
+			assert.Equal(t, nhttp.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), `"title of shelves/1/books/2"`)
+			assert.NotContains(t, w.Body.String(), `"title":`)
+
+			// the body field is decoded next to the nested path variable
+			w = serve(nhttp.MethodPatch, "/v1/shelves/1/books/2", `{"title":"new"}`)
+			assert.Equal(t, nhttp.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), `"name":"shelves/1/books/2"`)
+			assert.Contains(t, w.Body.String(), `"title":"new"`)
+
+			// custom verbs are matched literally
+			w = serve(nhttp.MethodPost, "/v1/shelves/1/books:move", `{"book":"b"}`)
+			assert.Equal(t, nhttp.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), `"name":"shelves/1/b"`)
+			assert.NotContains(t, w.Body.String(), "position")
+
+			w = serve(nhttp.MethodPost, "/v1/shelves/1/books:copy", `{"book":"b"}`)
+			assert.Equal(t, nhttp.StatusNotFound, w.Code)
+
+			routes := make(map[string]string)
+			for _, ri := range srv.Routes() {
+				routes[ri.Method+" "+ri.Path] = ri.Operation
+			}
+
+			assert.Equal(t, library.OperationLibraryServiceGetBook, routes["GET /v1/books/*name.0"])
+			assert.Equal(t, library.OperationLibraryServiceMoveBook, routes["POST /v1/shelves/:shelf.id/books:move"])
+		})
+	}
+}
+
+func TestGeneratedClientHTTPRule(t *testing.T) {
+	ctx := context.Background()
+	srv := http.NewServer()
+	library.RegisterLibraryServiceHTTPServer(srv, &libraryServer{})
+
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	defer func() {
+		_ = srv.Stop(ctx)
+	}()
+
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+
+	client, err := http.NewClient(ctx, http.WithEndpoint(e.Host))
+	assert.NoError(t, err)
+	defer client.Close()
+
+	lc := library.NewLibraryServiceHTTPClient(client)
+	book, err := lc.GetBook(ctx, &library.GetBookRequest{Name: "shelves/1/books/2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "title of shelves/1/books/2", book.GetTitle())
+
+	title, err := lc.GetBookTitle(ctx, &library.GetBookRequest{Name: "shelves/1/books/2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "title of shelves/1/books/2", title.GetTitle())
+
+	book, err = lc.UpdateBook(ctx, &library.UpdateBookRequest{Book: &library.Book{Name: "shelves/1/books/2", Title: "new"}})
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(&library.Book{Name: "shelves/1/books/2", Title: "new"}, book))
+
+	moved, err := lc.MoveBook(ctx, &library.MoveBookRequest{Shelf: &library.Shelf{Id: "1"}, Book: "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "shelves/1/b", moved.GetBook().GetName())
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const unixNetwork = "unix"
+
+// listenUnix listens on a unix domain socket, a stale socket file left by a
+// previous process is removed first. Addresses starting with @ are abstract
+// sockets and have no file. The socket file is removed when the listener is
+// closed.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	abstract := strings.HasPrefix(path, "@")
+	if !abstract {
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+	}
+
+	lis, err := net.Listen(unixNetwork, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != 0 && !abstract {
+		if err := os.Chmod(path, mode); err != nil {
+			_ = lis.Close()
+			return nil, err
+		}
+	}
+
+	return lis, nil
+}
+
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a unix socket", path)
+	}
+
+	if conn, err := net.DialTimeout(unixNetwork, path, time.Second); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("unix socket %s is already in use", path)
+	}
+
+	return os.Remove(path)
+}
+
+// unixEndpoint returns the endpoint of a unix socket in grpc target syntax,
+// unix:///path or unix-abstract:name.
+func unixEndpoint(path string) *url.URL {
+	if name, ok := strings.CutPrefix(path, "@"); ok {
+		return &url.URL{Scheme: "unix-abstract", Opaque: name}
+	}
+
+	return &url.URL{Scheme: unixNetwork, Path: path}
+}
@@ -0,0 +1,91 @@
+package http_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/internal/mock/ping"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T, srv *http.Server) {
+	ping.RegisterPingServiceHTTPServer(srv, &pingServer{})
+	go func() {
+		if err := srv.Start(context.Background()); err != nil {
+			panic(err)
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "http.sock")
+
+	// leave a stale socket behind
+	stale, err := net.Listen("unix", path)
+	assert.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	assert.NoError(t, stale.Close())
+
+	srv := http.NewServer(http.Network("unix"), http.Address(path), http.UnixSocketMode(0o660))
+	startServer(t, srv)
+
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+	assert.Equal(t, "unix://"+path, e.String())
+
+	fi, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o660), fi.Mode().Perm())
+
+	client := &nhttp.Client{Transport: &nhttp.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+
+	res, err := client.Get("http://unix/ping")
+	assert.NoError(t, err)
+	assert.Equal(t, nhttp.StatusOK, res.StatusCode)
+	_ = res.Body.Close()
+
+	// the socket is in use
+	err = http.NewServer(http.Network("unix"), http.Address(path)).Start(context.Background())
+	assert.ErrorContains(t, err, "already in use")
+
+	assert.NoError(t, srv.Stop(context.Background()))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestListenerAndH2C(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := http.NewServer(http.Listener(lis), http.H2C(true))
+	startServer(t, srv)
+	t.Cleanup(func() {
+		_ = srv.Stop(context.Background())
+	})
+
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://"+lis.Addr().String(), e.String())
+
+	protocols := new(nhttp.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &nhttp.Client{Transport: &nhttp.Transport{Protocols: protocols}}
+
+	res, err := client.Get(e.String() + "/ping")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.ProtoMajor)
+	_ = res.Body.Close()
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	DefaultSwaggerUIPath   = "/swagger"
+	DefaultSwaggerUIAssets = "https://unpkg.com/swagger-ui-dist@5"
+)
+
+var swaggerUITemplate = template.Must(template.New("swagger-ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Swagger UI</title>
+  <link rel="stylesheet" href="{{.Assets}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.Assets}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`))
+
+// openAPIDoc serves an OpenAPI document and the Swagger UI for it.
+type openAPIDoc struct {
+	spec        []byte
+	contentType string
+	path        string
+	uiPath      string
+	uiAssets    string
+}
+
+type OpenAPIOption func(*openAPIDoc)
+
+// OpenAPIPath sets the path of the document, the default is /openapi.json
+// for json documents and /openapi.yaml otherwise.
+func OpenAPIPath(path string) OpenAPIOption {
+	return func(d *openAPIDoc) {
+		d.path = path
+	}
+}
+
+// SwaggerUI sets the path of the Swagger UI page, the default is /swagger and
+// an empty path only serves the document.
+func SwaggerUI(path string) OpenAPIOption {
+	return func(d *openAPIDoc) {
+		d.uiPath = path
+	}
+}
+
+// SwaggerUIAssets sets the base URL of the swagger-ui-dist files loaded by the
+// page, e.g. to serve them with Static instead of a CDN.
+func SwaggerUIAssets(baseURL string) OpenAPIOption {
+	return func(d *openAPIDoc) {
+		d.uiAssets = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+func newOpenAPIDoc(spec []byte, opts ...OpenAPIOption) *openAPIDoc {
+	d := &openAPIDoc{
+		spec:        spec,
+		contentType: "application/yaml",
+		path:        "/openapi.yaml",
+		uiPath:      DefaultSwaggerUIPath,
+		uiAssets:    DefaultSwaggerUIAssets,
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(spec), []byte("{")) {
+		d.contentType, d.path = "application/json", "/openapi.json"
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+func (d *openAPIDoc) serveSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", d.contentType+"; charset=utf-8")
+	_, _ = w.Write(d.spec)
+}
+
+func (d *openAPIDoc) serveUI(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	err := swaggerUITemplate.Execute(&buf, map[string]string{
+		"Assets":  d.uiAssets,
+		"SpecURL": path.Clean("/" + d.path),
+	})
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *Server) registerOpenAPI() {
+	d := s.openAPI
+	s.Route(http.MethodGet, path.Clean("/"+d.path), "", http.HandlerFunc(d.serveSpec))
+	if d.uiPath != "" {
+		s.Route(http.MethodGet, path.Clean("/"+d.uiPath), "", http.HandlerFunc(d.serveUI))
+	}
+}
@@ -0,0 +1,47 @@
+package http_test
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPI(t *testing.T) {
+	spec, err := os.ReadFile("../../internal/mock/library/library.openapi.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	srv := http.NewServer(http.OpenAPI(spec))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodGet, "/openapi.yaml", nil))
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Equal(t, "application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, spec, w.Body.Bytes())
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodGet, http.DefaultSwaggerUIPath, nil))
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), http.DefaultSwaggerUIAssets+"/swagger-ui-bundle.js")
+	assert.Contains(t, w.Body.String(), `url: "/openapi.yaml"`)
+
+	// json documents, custom paths and no ui
+	srv = http.NewServer(
+		http.UseRouter(http.ServeMuxRouter()),
+		http.OpenAPI([]byte(`{"openapi":"3.1.0"}`), http.OpenAPIPath("/docs/api.json"), http.SwaggerUI("")),
+	)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodGet, "/docs/api.json", nil))
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodGet, http.DefaultSwaggerUIPath, nil))
+	assert.Equal(t, nhttp.StatusNotFound, w.Code)
+}
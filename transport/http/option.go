@@ -2,12 +2,21 @@
 
 import (
 	"crypto/tls"
+	"io/fs"
+	"net"
+	"net/http"
 	"net/url"
+	"os"
+	"path"
 	"time"
 
+	"github.com/dizzrt/ellie/middleware"
+	"github.com/dizzrt/ellie/registry"
 	"github.com/gin-gonic/gin"
 )
 
+// region ServerOption
+
 type ServerOption func(*Server)
 
 func TLSConfig(tlsConfig *tls.Config) ServerOption {
@@ -22,6 +31,16 @@ func Endpoint(endpoint *url.URL) ServerOption {
 	}
 }
 
+// Listener sets the listener of the server, the network and address are
+// taken from the listener.
+func Listener(lis net.Listener) ServerOption {
+	return func(s *Server) {
+		s.lis = lis
+		s.network = lis.Addr().Network()
+		s.address = lis.Addr().String()
+	}
+}
+
 func Network(network string) ServerOption {
 	return func(s *Server) {
 		s.network = network
@@ -34,6 +53,21 @@ func Address(address string) ServerOption {
 	}
 }
 
+// UnixSocketMode sets the file mode of the socket created for the unix
+// network, e.g. 0660 to allow the group to connect.
+func UnixSocketMode(mode os.FileMode) ServerOption {
+	return func(s *Server) {
+		s.unixSocketMode = mode
+	}
+}
+
+// H2C enables cleartext HTTP/2 with prior knowledge next to HTTP/1.
+func H2C(enable bool) ServerOption {
+	return func(s *Server) {
+		s.h2c = enable
+	}
+}
+
 func Timeout(timeout time.Duration) ServerOption {
 	return func(s *Server) {
 		s.timeout = timeout
@@ -58,6 +92,97 @@ func ResponseEncoder(encoder HTTPResponseEncoder) ServerOption {
 	}
 }
 
+// ProblemTypeBaseURI sets the prefix of problem type URIs, the lower kebab
+// case error reason is appended to it. The default is urn:problem-type:.
+func ProblemTypeBaseURI(base string) ServerOption {
+	return func(s *Server) {
+		s.problemTypeBaseURI = base
+	}
+}
+
+// StreamHeartbeat sets the interval of heartbeat comments on idle event
+// streams, the default is 15s and a negative value disables them.
+func StreamHeartbeat(interval time.Duration) ServerOption {
+	return func(s *Server) {
+		s.streamHeartbeat = interval
+	}
+}
+
+// WebSocketCheckOrigin sets the origin check of websocket upgrades, by default
+// cross origin upgrades are rejected.
+func WebSocketCheckOrigin(fn func(r *http.Request) bool) ServerOption {
+	return func(s *Server) {
+		s.webSocketCheckOrigin = fn
+	}
+}
+
+// DebugRoutes serves the registered routes as json on /debug/routes.
+func DebugRoutes(enable bool) ServerOption {
+	return func(s *Server) {
+		s.debugRoutes = enable
+	}
+}
+
+// OpenAPI serves spec, e.g. an embedded document generated by
+// protoc-gen-ellie-openapi, and a Swagger UI page for it.
+func OpenAPI(spec []byte, opts ...OpenAPIOption) ServerOption {
+	return func(s *Server) {
+		s.openAPI = newOpenAPIDoc(spec, opts...)
+	}
+}
+
+// MountGRPC serves the services of a grpc server, e.g. *grpc.Server, to
+// gRPC-Web and Connect clients. Requests are recognized by their content type,
+// Connect unary requests by the Connect-Protocol-Version header, and are
+// translated to grpc calls served by srv. Only the methods srv serves are
+// bridged, as listed by GetServiceInfo or else the global proto registry,
+// other requests are served by the router. JSON messages are supported for the
+// services in the global proto registry.
+func MountGRPC(srv http.Handler) ServerOption {
+	return func(s *Server) {
+		s.grpcService = srv
+	}
+}
+
+// Static serves the files of fsys, e.g. an embed.FS, below prefix. Files are
+// only served for requests that match no route, before the NoRoute handlers.
+// Responses carry an ETag and Last-Modified when the file has a modification
+// time, and .br or .gz variants next to a file are served to clients that
+// accept them.
+func Static(prefix string, fsys fs.FS, opts ...StaticOption) ServerOption {
+	return func(s *Server) {
+		m := &staticMount{
+			prefix: path.Clean("/" + prefix),
+			fsys:   fsys,
+			index:  defaultStaticIndex,
+		}
+
+		for _, opt := range opts {
+			opt(m)
+		}
+
+		s.statics = append(s.statics, m)
+	}
+}
+
+// MaxRequestBodySize limits the size of request bodies, larger bodies are
+// rejected with ErrRequestEntityTooLarge. Compressed bodies are limited after
+// decoding.
+func MaxRequestBodySize(size int64) ServerOption {
+	return func(s *Server) {
+		s.maxRequestBodySize = size
+	}
+}
+
+// DecompressRequestBody decodes request bodies sent with a gzip, deflate or
+// zstd Content-Encoding, other encodings are rejected with
+// ErrUnsupportedMediaType.
+func DecompressRequestBody(enable bool) ServerOption {
+	return func(s *Server) {
+		s.decompressRequestBody = enable
+	}
+}
+
 func RedirectTrailingSlash(isStrict bool) ServerOption {
 	return func(s *Server) {
 		s.redirectTrailingSlash = isStrict
@@ -76,8 +201,118 @@ func NoMethodHandlers(handler gin.HandlerFunc) ServerOption {
 	}
 }
 
+// Middleware adds gin middleware, it only applies to gin routers.
 func Middleware(middleware ...gin.HandlerFunc) ServerOption {
 	return func(s *Server) {
-		s.engine.Use(middleware...)
+		s.middleware = append(s.middleware, middleware...)
+	}
+}
+
+// UseRouter sets the router of the server, see GinRouter and ServeMuxRouter.
+// The default is a gin router with gin's logger and recovery middleware.
+func UseRouter(router Router) ServerOption {
+	return func(s *Server) {
+		s.router = router
+	}
+}
+
+// NotFoundHandler sets the handler of requests that match neither a route nor
+// a mounted file, it runs after the NoRouteHandlers of gin routers.
+func NotFoundHandler(handler http.Handler) ServerOption {
+	return func(s *Server) {
+		s.notFoundHandler = handler
+	}
+}
+
+func Filter(filters ...FilterFunc) ServerOption {
+	return func(s *Server) {
+		s.filters = append(s.filters, filters...)
+	}
+}
+
+// endregion
+
+// region ClientOption
+
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	endpoint    string
+	tlsConf     *tls.Config
+	timeout     time.Duration
+	discovery   registry.Discovery
+	middleware  []middleware.Middleware
+	transport   http.RoundTripper
+	contentType string
+	userAgent   string
+	successCode int
+
+	printDiscoveryDebugLog bool
+}
+
+// WithEndpoint sets the target, either "host:port", a full http(s) url or
+// "discovery:///<service-name>" together with WithDiscovery.
+func WithEndpoint(endpoint string) ClientOption {
+	return func(o *clientOptions) {
+		o.endpoint = endpoint
+	}
+}
+
+func WithTLSConfig(tlsConf *tls.Config) ClientOption {
+	return func(o *clientOptions) {
+		o.tlsConf = tlsConf
 	}
 }
+
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.timeout = timeout
+	}
+}
+
+func WithDiscovery(discovery registry.Discovery) ClientOption {
+	return func(o *clientOptions) {
+		o.discovery = discovery
+	}
+}
+
+func WithMiddleware(m ...middleware.Middleware) ClientOption {
+	return func(o *clientOptions) {
+		o.middleware = m
+	}
+}
+
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(o *clientOptions) {
+		o.transport = transport
+	}
+}
+
+// WithContentType sets the default content type used to encode requests.
+func WithContentType(contentType string) ClientOption {
+	return func(o *clientOptions) {
+		o.contentType = contentType
+	}
+}
+
+func WithUserAgent(userAgent string) ClientOption {
+	return func(o *clientOptions) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithSuccessCode sets the envelope status regarded as success, it should
+// match the server's DefaultSuccessCode.
+func WithSuccessCode(code int) ClientOption {
+	return func(o *clientOptions) {
+		o.successCode = code
+	}
+}
+
+func WithPrintDiscoveryDebugLog(print bool) ClientOption {
+	return func(o *clientOptions) {
+		o.printDiscoveryDebugLog = print
+	}
+}
+
+// endregion
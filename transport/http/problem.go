@@ -0,0 +1,148 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dizzrt/ellie/encoding"
+	"github.com/dizzrt/ellie/encoding/json"
+	"github.com/dizzrt/ellie/errors"
+	"github.com/gin-gonic/gin/render"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+
+	defaultProblemTypeBaseURI = "urn:problem-type:"
+)
+
+// problem members defined by RFC 9457 and the code and reason extensions read
+// by the client, metadata and error details must not override them
+var problemMembers = map[string]struct{}{
+	"type":     {},
+	"title":    {},
+	"status":   {},
+	"detail":   {},
+	"instance": {},
+	"code":     {},
+	"reason":   {},
+}
+
+// ProblemResponseEncoder renders errors as RFC 9457 application/problem+json
+// documents, successful responses are rendered by DefaultResponseEncoder.
+func ProblemResponseEncoder(r *http.Request, data any, err error, s *Server) (int, render.Render) {
+	if err == nil {
+		return DefaultResponseEncoder(r, data, err, s)
+	}
+
+	code := HTTPStatusCodeFromError(err)
+	if code < http.StatusBadRequest {
+		code = http.StatusInternalServerError
+	}
+
+	return code, &codecRender{
+		codec:       encoding.GetCodec(json.Name),
+		contentType: ProblemContentType,
+		data:        s.problem(r, code, err),
+	}
+}
+
+// problem builds the problem document of err, the type URI is derived from
+// the error reason and Metadata and error details become extension members.
+func (s *Server) problem(r *http.Request, code int, err error) map[string]any {
+	se := errors.NewStandardErrorFromError(err)
+	doc := map[string]any{
+		"type":     "about:blank",
+		"title":    http.StatusText(code),
+		"status":   code,
+		"detail":   se.Message(),
+		"instance": r.URL.Path,
+		"code":     se.Code(),
+	}
+
+	if reason := se.Reason(); reason != "" {
+		base := s.problemTypeBaseURI
+		if base == "" {
+			base = defaultProblemTypeBaseURI
+		}
+
+		doc["type"] = base + strings.ToLower(strings.ReplaceAll(reason, "_", "-"))
+		doc["reason"] = reason
+	}
+
+	if se.Message() == "" {
+		delete(doc, "detail")
+	}
+
+	for k, v := range se.Metadata() {
+		addProblemMember(doc, k, v)
+	}
+
+	if st, ok := status.FromError(err); ok {
+		addProblemDetails(doc, st.Details())
+	}
+
+	return doc
+}
+
+func addProblemMember(doc map[string]any, key string, value any) {
+	if _, ok := problemMembers[key]; ok {
+		return
+	}
+
+	doc[key] = value
+}
+
+func addProblemDetails(doc map[string]any, details []any) {
+	for _, detail := range details {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			addProblemMember(doc, "domain", d.GetDomain())
+			for k, v := range d.GetMetadata() {
+				addProblemMember(doc, k, v)
+			}
+		case *errdetails.BadRequest:
+			params := make([]map[string]string, 0, len(d.GetFieldViolations()))
+			for _, fv := range d.GetFieldViolations() {
+				params = append(params, map[string]string{
+					"name":   fv.GetField(),
+					"reason": fv.GetDescription(),
+				})
+			}
+
+			addProblemMember(doc, "invalid-params", params)
+		case *errdetails.RetryInfo:
+			addProblemMember(doc, "retry-after", d.GetRetryDelay().AsDuration().String())
+		case *errdetails.QuotaFailure:
+			violations := make([]map[string]string, 0, len(d.GetViolations()))
+			for _, v := range d.GetViolations() {
+				violations = append(violations, map[string]string{
+					"subject":     v.GetSubject(),
+					"description": v.GetDescription(),
+				})
+			}
+
+			addProblemMember(doc, "quota-violations", violations)
+		case *errdetails.ResourceInfo:
+			addProblemMember(doc, "resource", map[string]string{
+				"type":  d.GetResourceType(),
+				"name":  d.GetResourceName(),
+				"owner": d.GetOwner(),
+			})
+		case *errdetails.Help:
+			links := make([]map[string]string, 0, len(d.GetLinks()))
+			for _, link := range d.GetLinks() {
+				links = append(links, map[string]string{
+					"description": link.GetDescription(),
+					"url":         link.GetUrl(),
+				})
+			}
+
+			addProblemMember(doc, "help", links)
+		case *errdetails.LocalizedMessage:
+			addProblemMember(doc, "localized-message", d.GetMessage())
+		}
+	}
+}
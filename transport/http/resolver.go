@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dizzrt/ellie/internal/endpoint"
+	"github.com/dizzrt/ellie/log"
+	"github.com/dizzrt/ellie/registry"
+)
+
+const discoveryScheme = "discovery"
+
+var ErrNoAvailableEndpoint = errors.New("no available endpoint")
+
+// resolver keeps the http endpoints of a discovered service up to date and
+// picks one per request in round robin order.
+type resolver struct {
+	w        registry.Watcher
+	scheme   string
+	debugLog bool
+
+	mu        sync.RWMutex
+	endpoints []string
+	next      atomic.Uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newResolver(ctx context.Context, d registry.Discovery, target string, insecure bool, timeout time.Duration, debugLog bool) (*resolver, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	w, err := d.Watch(ctx, strings.TrimPrefix(target, "/"))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	r := &resolver{
+		w:        w,
+		scheme:   endpoint.Scheme("http", !insecure),
+		debugLog: debugLog,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		ins, err := w.Next()
+		if err == nil {
+			r.update(ins)
+		}
+
+		done <- err
+	}()
+
+	if timeout > 0 {
+		select {
+		case err = <-done:
+		case <-time.After(timeout):
+			err = fmt.Errorf("discovery resolve %s overtime", target)
+		}
+	} else {
+		err = <-done
+	}
+
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	go r.watch()
+	return r, nil
+}
+
+func (r *resolver) update(ins []*registry.ServiceInstance) {
+	endpoints := make([]string, 0, len(ins))
+	seen := make(map[string]struct{}, len(ins))
+	for _, in := range ins {
+		ept, err := endpoint.Parse(in.Endpoints, r.scheme)
+		if err != nil {
+			log.Errorf("[HTTP] failed to parse discovery endpoint, err: %v", err)
+			continue
+		}
+
+		if ept == "" {
+			continue
+		}
+
+		if _, ok := seen[ept]; ok {
+			continue
+		}
+
+		seen[ept] = struct{}{}
+		endpoints = append(endpoints, ept)
+	}
+
+	if len(endpoints) == 0 {
+		log.Errorf("[HTTP] zero endpoint found, refused to write, instances: %v", ins)
+		return
+	}
+
+	r.mu.Lock()
+	r.endpoints = endpoints
+	r.mu.Unlock()
+
+	if r.debugLog {
+		log.Infof("[HTTP] resolver update endpoints: %v", endpoints)
+	}
+}
+
+func (r *resolver) watch() {
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		default:
+		}
+
+		ins, err := r.w.Next()
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+
+			log.Errorf("[HTTP] failed to watch discovery endpoint: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		r.update(ins)
+	}
+}
+
+func (r *resolver) pick() (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.endpoints) == 0 {
+		return "", ErrNoAvailableEndpoint
+	}
+
+	idx := r.next.Add(1) - 1
+	return r.endpoints[idx%uint64(len(r.endpoints))], nil
+}
+
+func (r *resolver) Close() {
+	r.cancel()
+	if err := r.w.Stop(); err != nil {
+		log.Errorf("[HTTP] failed to stop watcher: %v", err)
+	}
+}
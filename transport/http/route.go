@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+)
+
+const DebugRoutesPath = "/debug/routes"
+
+// RouteInfo describes a route registered on the server.
+type RouteInfo struct {
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Operation string `json:"operation,omitempty"`
+	Handler   string `json:"handler"`
+}
+
+// Route registers handler for method and path on the router, operation is the
+// full name of the rpc served by the route, e.g. /PingService/Ping. Generated
+// code registers every method through Route.
+func (s *Server) Route(method, path, operation string, handler http.Handler) {
+	s.router.Handle(method, path, handler)
+
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+
+	s.routes[routeKey(method, path)] = RouteInfo{
+		Method:    method,
+		Path:      path,
+		Operation: operation,
+		Handler:   handlerName(handler),
+	}
+}
+
+// Routes returns all routes registered on the router, including the ones
+// added without Route.
+func (s *Server) Routes() []RouteInfo {
+	routes := s.router.Routes()
+
+	s.routesMu.RLock()
+	defer s.routesMu.RUnlock()
+
+	for i, r := range routes {
+		if ri, ok := s.routes[routeKey(r.Method, r.Path)]; ok {
+			routes[i] = ri
+		}
+	}
+
+	return routes
+}
+
+func (s *Server) debugRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	s.WriteResponse(w, r, s.Routes(), nil)
+}
+
+func (s *Server) registerDebugRoutes() {
+	s.Route(http.MethodGet, DebugRoutesPath, "", http.HandlerFunc(s.debugRoutesHandler))
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
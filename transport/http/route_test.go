@@ -0,0 +1,66 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/internal/mock/ping"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutes(t *testing.T) {
+	srv := http.NewServer(http.DebugRoutes(true))
+	ping.RegisterPingServiceHTTPServer(srv, &pingServer{})
+	srv.Engine().GET("/healthz", func(ctx *gin.Context) {})
+
+	routes := make(map[string]http.RouteInfo)
+	for _, r := range srv.Routes() {
+		routes[r.Method+" "+r.Path] = r
+	}
+
+	assert.Len(t, routes, 4)
+	assert.Equal(t, ping.OperationPingServicePing, routes["GET /ping"].Operation)
+	assert.Contains(t, routes["GET /ping"].Handler, "_ping_PingService_GET_Ping_HTTP_Handler")
+	assert.Equal(t, ping.OperationPingServiceHello, routes["POST /hello/:name"].Operation)
+	assert.Empty(t, routes["GET /healthz"].Operation)
+	assert.Contains(t, routes, "GET "+http.DebugRoutesPath)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodGet, http.DebugRoutesPath, nil))
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+
+	var res struct {
+		Data []http.RouteInfo `json:"data"`
+	}
+
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.ElementsMatch(t, srv.Routes(), res.Data)
+}
+
+func TestRoutesConcurrent(t *testing.T) {
+	srv := http.NewServer(http.DebugRoutes(true), http.UseRouter(http.ServeMuxRouter()))
+
+	// routes can be added while the debug endpoint is served, e.g. by the gateway
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 50 {
+			srv.Route(nhttp.MethodGet, "/r"+strconv.Itoa(i), "", nhttp.NotFoundHandler())
+		}
+	}()
+
+	for range 50 {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodGet, http.DebugRoutesPath, nil))
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+	}
+
+	<-done
+	assert.Len(t, srv.Routes(), 51)
+}
@@ -0,0 +1,363 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// net/http types used by generated code, which imports this package as http.
+type (
+	Handler        = http.Handler
+	HandlerFunc    = http.HandlerFunc
+	ResponseWriter = http.ResponseWriter
+	Request        = http.Request
+)
+
+var (
+	_ Router = (*ginRouter)(nil)
+	_ Router = (*serveMuxRouter)(nil)
+)
+
+// Router matches requests to the handlers registered by Server.Route. Paths
+// use the gin syntax emitted by protoc-gen-ellie-go-http, :name matches a
+// segment and a trailing *name the rest of the path, a literal last segment
+// may end with a custom verb, e.g. /books:move. Routers pass the matched
+// values to the handler with WithPathParams.
+type Router interface {
+	http.Handler
+
+	Handle(method, path string, handler http.Handler)
+	// NotFound sets the handler of requests that don't match any route.
+	NotFound(handler http.Handler)
+	Routes() []RouteInfo
+}
+
+type pathParamsKey struct{}
+
+// WithPathParams returns a shallow copy of r carrying the path parameters
+// matched by the router.
+func WithPathParams(r *http.Request, params url.Values) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
+}
+
+// PathParams returns the path parameters of a request served by a Router.
+func PathParams(r *http.Request) url.Values {
+	params, _ := r.Context().Value(pathParamsKey{}).(url.Values)
+	return params
+}
+
+// region gin
+
+// verbParam matches the custom verbs of gin routes, e.g. the :move of
+// /books:move, gin can't match a colon literally.
+const verbParam = "ellie.verb"
+
+type ginRouter struct {
+	engine *gin.Engine
+
+	mu    sync.RWMutex
+	verbs map[string]map[string]http.Handler
+}
+
+// GinRouter routes requests with engine, gin.New() is used when it's nil. The
+// default router is backed by gin.Default(), which adds gin's logger and
+// recovery middleware. Gin specific options such as Middleware and
+// NoRouteHandlers only apply to gin routers.
+func GinRouter(engine *gin.Engine) Router {
+	if engine == nil {
+		engine = gin.New()
+	}
+
+	return &ginRouter{engine: engine, verbs: make(map[string]map[string]http.Handler)}
+}
+
+func (r *ginRouter) Handle(method, path string, handler http.Handler) {
+	base, verb, ok := splitVerb(path)
+	if !ok {
+		r.engine.Handle(method, path, ginHandler(handler))
+		return
+	}
+
+	// the verbs of a path share one route and are dispatched by value
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := routeKey(method, base)
+	verbs, exists := r.verbs[key]
+	if !exists {
+		verbs = make(map[string]http.Handler)
+		r.verbs[key] = verbs
+		r.engine.Handle(method, base+":"+verbParam, func(ctx *gin.Context) {
+			// the param follows the literal prefix, so its value keeps the colon
+			var h http.Handler
+			if verb, ok := strings.CutPrefix(ctx.Param(verbParam), ":"); ok {
+				r.mu.RLock()
+				h = verbs[verb]
+				r.mu.RUnlock()
+			}
+
+			if h == nil {
+				http.NotFound(ctx.Writer, ctx.Request)
+				return
+			}
+
+			ctx.Params = ctx.Params[:len(ctx.Params)-1]
+			ginHandler(h)(ctx)
+		})
+	}
+
+	if _, exists = verbs[verb]; exists {
+		panic(fmt.Sprintf("handlers are already registered for path '%s'", path))
+	}
+
+	verbs[verb] = handler
+}
+
+func ginHandler(handler http.Handler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := ctx.Request
+		if len(ctx.Params) > 0 {
+			params := make(url.Values, len(ctx.Params))
+			for _, p := range ctx.Params {
+				params.Set(p.Key, p.Value)
+			}
+
+			req = WithPathParams(req, params)
+		}
+
+		handler.ServeHTTP(ctx.Writer, req)
+	}
+}
+
+// splitVerb splits the custom verb off a path whose last segment is a literal,
+// e.g. /v1/books:move.
+func splitVerb(path string) (string, string, bool) {
+	last := path[strings.LastIndex(path, "/")+1:]
+	i := strings.Index(last, ":")
+	if i <= 0 {
+		return "", "", false
+	}
+
+	return path[:len(path)-len(last)+i], last[i+1:], true
+}
+
+func (r *ginRouter) NotFound(handler http.Handler) {
+	r.engine.NoRoute(gin.WrapH(handler))
+}
+
+func (r *ginRouter) Routes() []RouteInfo {
+	routes := r.engine.Routes()
+	infos := make([]RouteInfo, 0, len(routes))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, ri := range routes {
+		base, ok := strings.CutSuffix(ri.Path, ":"+verbParam)
+		if !ok {
+			infos = append(infos, RouteInfo{Method: ri.Method, Path: ri.Path, Handler: ri.Handler})
+			continue
+		}
+
+		verbs := r.verbs[routeKey(ri.Method, base)]
+		names := make([]string, 0, len(verbs))
+		for verb := range verbs {
+			names = append(names, verb)
+		}
+
+		sort.Strings(names)
+		for _, verb := range names {
+			infos = append(infos, RouteInfo{Method: ri.Method, Path: base + ":" + verb, Handler: handlerName(verbs[verb])})
+		}
+	}
+
+	return infos
+}
+
+func (r *ginRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.engine.ServeHTTP(w, req)
+}
+
+// endregion
+
+// region net/http
+
+type serveMuxRouter struct {
+	mux *http.ServeMux
+
+	mu       sync.RWMutex
+	routes   []RouteInfo
+	verbs    map[string]map[string]http.Handler
+	notFound http.Handler
+}
+
+// ServeMuxRouter routes requests with the method and wildcard patterns of
+// http.ServeMux. Like gin it replies 404 to requests of a known path with
+// another method. A custom verb may also follow a variable, e.g.
+// /jobs/:name:cancel, it is matched against the end of the last segment.
+func ServeMuxRouter() Router {
+	r := &serveMuxRouter{mux: http.NewServeMux(), verbs: make(map[string]map[string]http.Handler)}
+	r.mux.HandleFunc("/", r.serveNotFound)
+	return r
+}
+
+func (r *serveMuxRouter) Handle(method, path string, handler http.Handler) {
+	base, verb, ok := splitVariableVerb(path)
+	if !ok {
+		base, verb = path, ""
+	}
+
+	pattern, names := muxPattern(base)
+
+	// the verbs of a pattern share one ServeMux route, "" stands for no verb
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := routeKey(method, pattern)
+	verbs, exists := r.verbs[key]
+	if !exists {
+		verbs = make(map[string]http.Handler)
+		r.verbs[key] = verbs
+		r.mux.Handle(method+" "+pattern, r.dispatch(pattern, names, verbs))
+	}
+
+	if _, exists = verbs[verb]; exists {
+		panic(fmt.Sprintf("handlers are already registered for path '%s'", path))
+	}
+
+	verbs[verb] = handler
+	r.routes = append(r.routes, RouteInfo{Method: method, Path: path, Handler: handlerName(handler)})
+}
+
+func (r *serveMuxRouter) dispatch(pattern string, names []string, verbs map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		values := make([]string, len(names))
+		for i := range names {
+			values[i] = req.PathValue("p" + strconv.Itoa(i))
+			if i == len(names)-1 && strings.HasSuffix(pattern, "...}") {
+				// catch-all values keep the leading slash as in gin
+				values[i] = "/" + values[i]
+			}
+		}
+
+		r.mu.RLock()
+		handler := verbs[""]
+		if last := len(values) - 1; last >= 0 {
+			v := values[last]
+			if i := strings.LastIndex(v, ":"); i > strings.LastIndex(v, "/")+1 {
+				if h := verbs[v[i+1:]]; h != nil {
+					handler, values[last] = h, v[:i]
+				}
+			}
+		}
+		r.mu.RUnlock()
+
+		if handler == nil {
+			r.serveNotFound(w, req)
+			return
+		}
+
+		if len(names) > 0 {
+			params := make(url.Values, len(names))
+			for i, name := range names {
+				params.Set(name, values[i])
+			}
+
+			req = WithPathParams(req, params)
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// splitVariableVerb splits the custom verb off a path whose last segment is a
+// variable, e.g. /jobs/:name:cancel.
+func splitVariableVerb(path string) (string, string, bool) {
+	last := path[strings.LastIndex(path, "/")+1:]
+	if !strings.HasPrefix(last, ":") && !strings.HasPrefix(last, "*") {
+		return "", "", false
+	}
+
+	i := strings.Index(last[1:], ":")
+	if i <= 0 {
+		return "", "", false
+	}
+
+	return path[:len(path)-len(last)+i+1], last[i+2:], true
+}
+
+func (r *serveMuxRouter) NotFound(handler http.Handler) {
+	r.mu.Lock()
+	r.notFound = handler
+	r.mu.Unlock()
+}
+
+func (r *serveMuxRouter) Routes() []RouteInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]RouteInfo(nil), r.routes...)
+}
+
+func (r *serveMuxRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.ServeHTTP(w, req)
+}
+
+func (r *serveMuxRouter) serveNotFound(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	handler := r.notFound
+	r.mu.RUnlock()
+
+	if handler == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	handler.ServeHTTP(w, req)
+}
+
+// muxPattern converts a gin path to a http.ServeMux pattern. Wildcards are
+// renamed to p0, p1... since gin names may contain characters ServeMux
+// rejects, the original names are returned in order.
+func muxPattern(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var names []string
+	for i, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			segments[i] = "{p" + strconv.Itoa(len(names)) + "}"
+			names = append(names, seg[1:])
+		case strings.HasPrefix(seg, "*"):
+			segments[i] = "{p" + strconv.Itoa(len(names)) + "...}"
+			names = append(names, seg[1:])
+		}
+	}
+
+	pattern := strings.Join(segments, "/")
+	if strings.HasSuffix(pattern, "/") {
+		// gin matches the exact path, ServeMux the whole subtree
+		pattern += "{$}"
+	}
+
+	return pattern, names
+}
+
+// endregion
+
+func handlerName(handler http.Handler) string {
+	if f, ok := handler.(http.HandlerFunc); ok {
+		return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	}
+
+	return fmt.Sprintf("%T", handler)
+}
@@ -0,0 +1,109 @@
+package http_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/internal/mock/ping"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeMuxRouter(t *testing.T) {
+	fsys := fstest.MapFS{"index.html": {Data: []byte("<html>app</html>")}}
+	srv := http.NewServer(
+		http.UseRouter(http.ServeMuxRouter()),
+		http.Static("/ui", fsys, http.SPAFallback(true)),
+		http.NotFoundHandler(nhttp.HandlerFunc(func(w nhttp.ResponseWriter, r *nhttp.Request) {
+			w.WriteHeader(nhttp.StatusTeapot)
+		})),
+	)
+
+	assert.Nil(t, srv.Engine())
+	ping.RegisterPingServiceHTTPServer(srv, &pingServer{})
+
+	var params, catchAll string
+	srv.Route(nhttp.MethodGet, "/files/:bucket/*path", "", nhttp.HandlerFunc(func(w nhttp.ResponseWriter, r *nhttp.Request) {
+		params, catchAll = http.PathParams(r).Get("bucket"), http.PathParams(r).Get("path")
+	}))
+
+	// custom verbs after a variable are dispatched on the end of the segment
+	var job, action string
+	for _, verb := range []string{"", ":cancel"} {
+		srv.Route(nhttp.MethodPost, "/jobs/:name"+verb, "", nhttp.HandlerFunc(func(w nhttp.ResponseWriter, r *nhttp.Request) {
+			job, action = http.PathParams(r).Get("name"), verb
+		}))
+	}
+
+	for path, want := range map[string][2]string{
+		"/jobs/a":        {"a", ""},
+		"/jobs/a:cancel": {"a", ":cancel"},
+		"/jobs/a:b":      {"a:b", ""},
+	} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodPost, path, nil))
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Equal(t, want, [2]string{job, action}, path)
+	}
+
+	r := httptest.NewRequest(nhttp.MethodPost, "/hello/ellie", strings.NewReader(`{"type":"mux"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "hello ellie, type is mux")
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodGet, "/files/docs/a/b.txt", nil))
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Equal(t, "docs", params)
+	assert.Equal(t, "/a/b.txt", catchAll)
+
+	// unmatched requests are served by the mounted files and the not found handler
+	r = httptest.NewRequest(nhttp.MethodGet, "/ui/settings", nil)
+	r.Header.Set("Accept", "text/html")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	assert.Equal(t, "<html>app</html>", w.Body.String())
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodGet, "/ping/extra", nil))
+	assert.Equal(t, nhttp.StatusTeapot, w.Code)
+
+	routes := make(map[string]http.RouteInfo)
+	for _, ri := range srv.Routes() {
+		routes[ri.Method+" "+ri.Path] = ri
+	}
+
+	assert.Len(t, routes, 5)
+	assert.Contains(t, routes, "POST /jobs/:name:cancel")
+	assert.Equal(t, ping.OperationPingServiceHello, routes["POST /hello/:name"].Operation)
+	assert.Contains(t, routes["GET /ping"].Handler, "_ping_PingService_GET_Ping_HTTP_Handler")
+}
+
+func TestGinRouter(t *testing.T) {
+	var calls int
+	srv := http.NewServer(
+		http.Middleware(func(ctx *gin.Context) {
+			calls++
+			ctx.Next()
+		}),
+		http.UseRouter(http.GinRouter(nil)),
+	)
+
+	ping.RegisterPingServiceHTTPServer(srv, &pingServer{})
+
+	// middleware set before the router still applies, gin's own isn't added
+	assert.Len(t, srv.Engine().Handlers, 1)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(nhttp.MethodGet, "/ping", nil))
+	assert.Equal(t, nhttp.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "pong")
+	assert.Equal(t, 1, calls)
+}
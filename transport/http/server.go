@@ -7,6 +7,8 @@
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/dizzrt/ellie/errors"
@@ -30,9 +32,11 @@ type Server struct {
 	err error
 	lis net.Listener
 
-	engine                *gin.Engine
+	router                Router
+	middleware            []gin.HandlerFunc
 	noRouteHandlers       []gin.HandlerFunc
 	NoMethodHandler       []gin.HandlerFunc
+	notFoundHandler       http.Handler
 	redirectTrailingSlash bool
 
 	tlsConf  *tls.Config
@@ -46,6 +50,20 @@ type Server struct {
 	defaultSuccessCode    int
 	defaultSuccessMessage string
 	responseEncoder       HTTPResponseEncoder
+	problemTypeBaseURI    string
+	streamHeartbeat       time.Duration
+	webSocketCheckOrigin  func(r *http.Request) bool
+	debugRoutes           bool
+	openAPI               *openAPIDoc
+	unixSocketMode        os.FileMode
+	h2c                   bool
+	grpcService           http.Handler
+	statics               []*staticMount
+	maxRequestBodySize    int64
+	decompressRequestBody bool
+
+	routesMu sync.RWMutex
+	routes   map[string]RouteInfo
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -56,38 +74,122 @@ func NewServer(opts ...ServerOption) *Server {
 		defaultSuccessCode:    0,
 		defaultSuccessMessage: "ok",
 		responseEncoder:       DefaultResponseEncoder,
-		engine:                gin.Default(),
 		redirectTrailingSlash: true,
+		routes:                make(map[string]RouteInfo),
 	}
 
-	if len(srv.noRouteHandlers) > 0 {
-		srv.engine.NoRoute(srv.noRouteHandlers...)
+	for _, opt := range opts {
+		opt(srv)
 	}
 
-	if len(srv.NoMethodHandler) > 0 {
-		srv.engine.NoMethod(srv.NoMethodHandler...)
+	if srv.router == nil {
+		srv.router = GinRouter(gin.Default())
 	}
 
-	for _, opt := range opts {
-		opt(srv)
+	srv.sortStatics()
+	if engine := srv.Engine(); engine != nil {
+		srv.configureEngine(engine)
+	} else if len(srv.statics) > 0 || srv.notFoundHandler != nil {
+		srv.router.NotFound(http.HandlerFunc(srv.serveNotFound))
+	}
+
+	if srv.debugRoutes {
+		srv.registerDebugRoutes()
+	}
+
+	if srv.openAPI != nil {
+		srv.registerOpenAPI()
+	}
+
+	var handler http.Handler = srv.router
+	if srv.grpcService != nil {
+		handler = srv.grpcBridgeHandler(handler)
+	}
+
+	if srv.maxRequestBodySize > 0 || srv.decompressRequestBody {
+		handler = srv.bodyHandler(handler)
 	}
 
-	srv.engine.RedirectTrailingSlash = srv.redirectTrailingSlash
 	srv.Server = &http.Server{
 		TLSConfig: srv.tlsConf,
-		Handler:   FilterChain(srv.filters...)(srv.engine),
+		Handler:   FilterChain(srv.filters...)(handler),
+	}
+
+	if srv.h2c {
+		srv.Server.Protocols = new(http.Protocols)
+		srv.Server.Protocols.SetHTTP1(true)
+		srv.Server.Protocols.SetHTTP2(true)
+		srv.Server.Protocols.SetUnencryptedHTTP2(true)
 	}
 
 	return srv
 }
 
+// Router returns the router the server dispatches requests to.
+func (s *Server) Router() Router {
+	return s.router
+}
+
+// Engine returns the gin engine of the router, it's nil for other routers.
 func (s *Server) Engine() *gin.Engine {
-	return s.engine
+	if r, ok := s.router.(*ginRouter); ok {
+		return r.engine
+	}
+
+	return nil
+}
+
+// configureEngine applies the gin specific options, the mounted files and the
+// not found handler are served from the NoRoute chain.
+func (s *Server) configureEngine(engine *gin.Engine) {
+	engine.Use(s.middleware...)
+
+	var noRouteHandlers []gin.HandlerFunc
+	if len(s.statics) > 0 {
+		noRouteHandlers = append(noRouteHandlers, s.staticHandler)
+	}
+
+	noRouteHandlers = append(noRouteHandlers, s.noRouteHandlers...)
+	if s.notFoundHandler != nil {
+		noRouteHandlers = append(noRouteHandlers, gin.WrapH(s.notFoundHandler))
+	}
+
+	if len(noRouteHandlers) > 0 {
+		engine.NoRoute(noRouteHandlers...)
+	}
+
+	if len(s.NoMethodHandler) > 0 {
+		engine.NoMethod(s.NoMethodHandler...)
+	}
+
+	engine.RedirectTrailingSlash = s.redirectTrailingSlash
+}
+
+// serveNotFound serves the mounted files and the not found handler of
+// routers other than gin.
+func (s *Server) serveNotFound(w http.ResponseWriter, r *http.Request) {
+	if s.serveStatic(w, r) {
+		return
+	}
+
+	if s.notFoundHandler != nil {
+		s.notFoundHandler.ServeHTTP(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
 }
 
 func (s *Server) initializeListenerAndEndpoint() error {
 	if s.lis == nil {
-		lis, err := net.Listen(s.network, s.address)
+		var lis net.Listener
+		var err error
+		if s.network == unixNetwork {
+			lis, err = listenUnix(s.address, s.unixSocketMode)
+		} else {
+			lis, err = net.Listen(s.network, s.address)
+		}
+
 		if err != nil {
 			s.err = err
 			return err
@@ -96,6 +198,10 @@ func (s *Server) initializeListenerAndEndpoint() error {
 		s.lis = lis
 	}
 
+	if s.endpoint == nil && s.network == unixNetwork {
+		s.endpoint = unixEndpoint(s.address)
+	}
+
 	if s.endpoint == nil {
 		addr, err := host.Extract(s.address, s.lis)
 		if err != nil {
@@ -109,37 +215,68 @@ func (s *Server) initializeListenerAndEndpoint() error {
 	return s.err
 }
 
+// WrapHTTPResponse returns the json envelope of data or err, errors also
+// carry their reason and metadata so clients can rebuild them.
 func (s *Server) WrapHTTPResponse(data any, err error) gin.H {
 	code := s.defaultSuccessCode
 	message := s.defaultSuccessMessage
 
+	var reason string
+	var metadata map[string]string
 	if err != nil {
 		if se, ok := err.(*errors.StandardError); ok {
 			// standard error
 			code = int(se.Code())
 			message = se.Message()
+			reason, metadata = se.Reason(), se.Metadata()
 		} else if st, ok := status.FromError(err); ok {
 			// grpc error
 			code = int(st.Code())
 			message = st.Message()
+			reason, metadata = errorInfo(st)
 		} else {
 			code = -1
 			message = err.Error()
 		}
 	}
 
-	return gin.H{
+	body := gin.H{
 		"data":    data,
 		"status":  code,
 		"message": message,
 	}
+
+	if reason != "" {
+		body["reason"] = reason
+	}
+
+	if len(metadata) > 0 {
+		body["metadata"] = metadata
+	}
+
+	return body
 }
 
 func (s *Server) EncodeResponse(ctx *gin.Context, data any, err error) {
-	code, r := s.responseEncoder(data, err, s)
+	code, r := s.responseEncoder(ctx.Request, data, err, s)
 	ctx.Render(code, r)
 }
 
+// WriteResponse renders data or err with the response encoder like
+// EncodeResponse, without a gin context.
+func (s *Server) WriteResponse(w http.ResponseWriter, r *http.Request, data any, err error) {
+	code, render := s.responseEncoder(r, data, err, s)
+	render.WriteContentType(w)
+	w.WriteHeader(code)
+	if bodyAllowedForStatus(code) {
+		_ = render.Render(w)
+	}
+}
+
+func bodyAllowedForStatus(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
 // region interfaces impl
 
 func (s *Server) Start(ctx context.Context) error {
@@ -152,6 +289,9 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 
 	log.Infof("[HTTP] server listening on %s", s.lis.Addr().String())
+	for _, r := range s.Routes() {
+		log.Debugf("[HTTP] route %-7s %s %s --> %s", r.Method, r.Path, r.Operation, r.Handler)
+	}
 
 	var err error
 	if s.tlsConf != nil {
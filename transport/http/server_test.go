@@ -4,6 +4,7 @@
 	"context"
 	"fmt"
 	"io"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -16,7 +17,6 @@
 	"github.com/dizzrt/ellie/transport/http"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/render"
-	trace_sdk "go.opentelemetry.io/otel/sdk/trace"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -40,13 +40,26 @@ func (s *pingServer) Hello(ctx context.Context, req *ping.HelloRequest) (*ping.H
 	}, nil
 }
 
+// serve sends a request with the header key-value pairs to srv and records
+// the reply.
+func serve(srv nhttp.Handler, method, target string, body io.Reader, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, body)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	return w
+}
+
 func TestHTTPServer(t *testing.T) {
 	ctx := context.Background()
 
 	var opts = []http.ServerOption{
 		http.DefaultSuccessCode(10000),
 		http.DefaultSuccessMessage("success"),
-		// http.ResponseEncoder(func(data any, err error, s *http.Server) (int, render.Render) {
+		// http.ResponseEncoder(func(_ *nhttp.Request, data any, err error, s *http.Server) (int, render.Render) {
 		// 	code := http.HTTPStatusCodeFromError(err)
 		// 	r := render.JSON{Data: gin.H{
 		// 		"data": data,
@@ -98,7 +111,7 @@ func TestHTTPServerWithTracing(t *testing.T) {
 	ctx := context.Background()
 
 	// init tracing provider
-	tp, err := tracing.Initialize(ctx,
+	_, shutdown, err := tracing.Initialize(ctx,
 		tracing.ServiceName("transport-test"),
 		tracing.ServiceVersion("v0.0.1-dev"),
 		tracing.Endpoint("localhost:4318"),
@@ -117,15 +130,13 @@ func TestHTTPServerWithTracing(t *testing.T) {
 		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 		defer cancel()
 
-		if sdkTp, ok := tp.(*trace_sdk.TracerProvider); ok {
-			sdkTp.Shutdown(ctx)
-		}
+		_ = shutdown(ctx)
 	}()
 
 	var opts = []http.ServerOption{
 		http.DefaultSuccessCode(10000),
 		http.DefaultSuccessMessage("success"),
-		http.ResponseEncoder(func(data any, err error, s *http.Server) (int, render.Render) {
+		http.ResponseEncoder(func(_ *nhttp.Request, data any, err error, s *http.Server) (int, render.Render) {
 			code := http.HTTPStatusCodeFromError(err)
 			r := render.JSON{Data: gin.H{
 				"data": data,
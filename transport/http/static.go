@@ -0,0 +1,290 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultStaticIndex = "index.html"
+
+// precompressed variants in order of preference
+var staticEncodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// staticMount serves the files of fsys below prefix.
+type staticMount struct {
+	prefix       string
+	fsys         fs.FS
+	index        string
+	spa          bool
+	cacheControl string
+
+	etags sync.Map
+}
+
+type StaticOption func(*staticMount)
+
+// SPAFallback serves the index file for GET requests of html pages that
+// don't match a file, so client side routes can be reloaded. Requests of
+// paths with an extension and non html requests are left to the NoRoute
+// handlers.
+func SPAFallback(enable bool) StaticOption {
+	return func(m *staticMount) {
+		m.spa = enable
+	}
+}
+
+// StaticIndex sets the file served for directories and by the SPA fallback,
+// the default is index.html.
+func StaticIndex(name string) StaticOption {
+	return func(m *staticMount) {
+		m.index = name
+	}
+}
+
+// StaticCacheControl sets the Cache-Control header of files other than the
+// index, which is always revalidated.
+func StaticCacheControl(value string) StaticOption {
+	return func(m *staticMount) {
+		m.cacheControl = value
+	}
+}
+
+type staticETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// serve writes the file for the request path and reports whether the request
+// was handled.
+func (m *staticMount) serve(w http.ResponseWriter, r *http.Request) bool {
+	name, ok := m.name(r.URL.Path)
+	if !ok {
+		return false
+	}
+
+	if m.serveFile(w, r, name, false) {
+		return true
+	}
+
+	if !m.spa || path.Ext(name) != "" || !acceptsHTML(r) {
+		return false
+	}
+
+	return m.serveFile(w, r, m.index, true)
+}
+
+// name maps the request path to a file name of fsys.
+func (m *staticMount) name(p string) (string, bool) {
+	p = path.Clean("/" + p)
+	if m.prefix != "/" {
+		if p != m.prefix && !strings.HasPrefix(p, m.prefix+"/") {
+			return "", false
+		}
+
+		p = strings.TrimPrefix(p, m.prefix)
+	}
+
+	name := strings.TrimPrefix(p, "/")
+	if name == "" {
+		name = "."
+	}
+
+	return name, fs.ValidPath(name)
+}
+
+func (m *staticMount) serveFile(w http.ResponseWriter, r *http.Request, name string, fallback bool) bool {
+	info, err := fs.Stat(m.fsys, name)
+	if err != nil {
+		return false
+	}
+
+	if info.IsDir() {
+		name = path.Join(name, m.index)
+		if info, err = fs.Stat(m.fsys, name); err != nil || info.IsDir() {
+			return false
+		}
+	}
+
+	h := w.Header()
+	isIndex := fallback || path.Base(name) == m.index
+	switch {
+	case isIndex:
+		h.Set("Cache-Control", "no-cache")
+	case m.cacheControl != "":
+		h.Set("Cache-Control", m.cacheControl)
+	}
+
+	file, encoding := name, ""
+	for _, enc := range staticEncodings {
+		if !acceptsEncoding(r, enc.name) {
+			continue
+		}
+
+		if vi, err := fs.Stat(m.fsys, name+enc.ext); err == nil && !vi.IsDir() {
+			file, encoding, info = name+enc.ext, enc.name, vi
+			break
+		}
+	}
+
+	h.Add("Vary", "Accept-Encoding")
+	if encoding != "" {
+		ctype := mime.TypeByExtension(path.Ext(name))
+		if ctype == "" {
+			ctype = "application/octet-stream"
+		}
+
+		h.Set("Content-Type", ctype)
+		h.Set("Content-Encoding", encoding)
+	}
+
+	content, err := m.open(file)
+	if err != nil {
+		return false
+	}
+	defer content.Close()
+
+	if etag, err := m.etag(file, info, content); err == nil {
+		h.Set("ETag", etag)
+	}
+
+	// zero times, e.g. of embed.FS, are not sent
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return true
+}
+
+type staticContent interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+func (m *staticMount) open(name string) (staticContent, error) {
+	f, err := m.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if rs, ok := f.(staticContent); ok {
+		return rs, nil
+	}
+
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return nopSeekCloser{bytes.NewReader(data)}, nil
+}
+
+type nopSeekCloser struct {
+	*bytes.Reader
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
+
+// etag returns the strong etag of a file, the hash of the content is cached
+// until the size or modification time changes.
+func (m *staticMount) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if v, ok := m.etags.Load(name); ok {
+		if e := v.(staticETag); e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+			return e.etag, nil
+		}
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	m.etags.Store(name, staticETag{modTime: info.ModTime(), size: info.Size(), etag: etag})
+	return etag, nil
+}
+
+func acceptsHTML(r *http.Request) bool {
+	for _, ar := range parseAccept(r.Header.Get("Accept")) {
+		if ar.q > 0 && (ar.mediaType == "text/html" || ar.mediaType == "application/xhtml+xml") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(v, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(q, 64)
+			return err == nil && f > 0
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// serveStatic serves the mounted files to requests that match no route, so
+// routes always win over files. It reports whether a file was served.
+func (s *Server) serveStatic(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	for _, mount := range s.statics {
+		if mount.serve(w, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// staticHandler serves the mounted files from the gin NoRoute chain, requests
+// that don't match a file are passed on to the NoRoute handlers.
+func (s *Server) staticHandler(ctx *gin.Context) {
+	if s.serveStatic(ctx.Writer, ctx.Request) {
+		ctx.Abort()
+		return
+	}
+
+	ctx.Next()
+}
+
+func (s *Server) sortStatics() {
+	// longest prefix first
+	sort.SliceStable(s.statics, func(i, j int) bool {
+		return len(s.statics[i].prefix) > len(s.statics[j].prefix)
+	})
+}
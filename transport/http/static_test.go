@@ -0,0 +1,109 @@
+package http_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatic(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"index.html":      {Data: []byte("<html>app</html>"), ModTime: modTime},
+		"app.js":          {Data: []byte("console.log('app')"), ModTime: modTime},
+		"app.js.br":       {Data: []byte("br-app"), ModTime: modTime},
+		"app.js.gz":       {Data: []byte("gz-app"), ModTime: modTime},
+		"health":          {Data: []byte("file"), ModTime: modTime},
+		"docs/index.html": {Data: []byte("<html>docs</html>"), ModTime: modTime},
+	}
+
+	srv := http.NewServer(
+		http.Static("/ui", fsys, http.SPAFallback(true), http.StaticCacheControl("max-age=3600")),
+		http.NoRouteHandlers(func(ctx *gin.Context) {
+			ctx.JSON(nhttp.StatusNotFound, gin.H{"message": "no route"})
+		}),
+	)
+
+	srv.Engine().GET("/ui/health", func(ctx *gin.Context) {
+		ctx.String(nhttp.StatusOK, "route")
+	})
+
+	t.Run("files", func(t *testing.T) {
+		w := serve(srv, nhttp.MethodGet, "/ui/app.js", nil)
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Equal(t, "console.log('app')", w.Body.String())
+		assert.Equal(t, "max-age=3600", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "Tue, 02 Jan 2024 03:04:05 GMT", w.Header().Get("Last-Modified"))
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		w = serve(srv, nhttp.MethodGet, "/ui/app.js", nil, "If-None-Match", etag)
+		assert.Equal(t, nhttp.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+
+		w = serve(srv, nhttp.MethodGet, "/ui/app.js", nil, "If-Modified-Since", "Tue, 02 Jan 2024 03:04:05 GMT")
+		assert.Equal(t, nhttp.StatusNotModified, w.Code)
+
+		// precompressed variants
+		w = serve(srv, nhttp.MethodGet, "/ui/app.js", nil, "Accept-Encoding", "gzip, br")
+		assert.Equal(t, "br-app", w.Body.String())
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+		w = serve(srv, nhttp.MethodGet, "/ui/app.js", nil, "Accept-Encoding", "br;q=0, gzip")
+		assert.Equal(t, "gz-app", w.Body.String())
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+		// directories serve their index, which is always revalidated
+		w = serve(srv, nhttp.MethodGet, "/ui/", nil)
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Equal(t, "<html>app</html>", w.Body.String())
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+		w = serve(srv, nhttp.MethodGet, "/ui/docs/", nil)
+		assert.Equal(t, "<html>docs</html>", w.Body.String())
+
+		w = serve(srv, nhttp.MethodHead, "/ui/app.js", nil)
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+
+		// routes win over files
+		w = serve(srv, nhttp.MethodGet, "/ui/health", nil)
+		assert.Equal(t, "route", w.Body.String())
+	})
+
+	t.Run("spa fallback", func(t *testing.T) {
+		html := []string{"Accept", "text/html,application/xhtml+xml,*/*;q=0.8"}
+
+		w := serve(srv, nhttp.MethodGet, "/ui/settings/profile", nil, html...)
+		assert.Equal(t, nhttp.StatusOK, w.Code)
+		assert.Equal(t, "<html>app</html>", w.Body.String())
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+		// missing assets, api requests and other prefixes reach the NoRoute handlers
+		for _, tt := range []struct {
+			method string
+			target string
+			header []string
+		}{
+			{nhttp.MethodGet, "/ui/missing.js", html},
+			{nhttp.MethodGet, "/ui/settings/profile", []string{"Accept", "application/json"}},
+			{nhttp.MethodGet, "/api/users", html},
+			{nhttp.MethodGet, "/ui/../index.html", html},
+			{nhttp.MethodPost, "/ui/app.js", nil},
+		} {
+			w = serve(srv, tt.method, tt.target, nil, tt.header...)
+			assert.Equal(t, nhttp.StatusNotFound, w.Code, tt.target)
+			assert.JSONEq(t, `{"message":"no route"}`, w.Body.String(), tt.target)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dizzrt/ellie/encoding"
+	"github.com/dizzrt/ellie/encoding/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	EventStreamContentType = "text/event-stream"
+	NDJSONContentType      = "application/x-ndjson"
+
+	defaultStreamHeartbeat = 15 * time.Second
+)
+
+var errStreamRecvNotSupported = fmt.Errorf("http stream: RecvMsg is not supported on server streams")
+
+var _ grpc.ServerStream = (*serverStream)(nil)
+
+// serverStream adapts an http response to grpc.ServerStream, each message is
+// written and flushed as a server-sent event or as a NDJSON line.
+type serverStream struct {
+	ctx    context.Context
+	w      http.ResponseWriter
+	codec  encoding.Codec
+	ndjson bool
+
+	mu          sync.Mutex
+	header      metadata.MD
+	wroteHeader bool
+	seq         int
+}
+
+// ServeServerStream runs a server-streaming handler over http. Messages are
+// rendered as text/event-stream unless the client accepts only
+// application/x-ndjson, SSE streams get heartbeat comments while idle. The
+// stream context is canceled when the client disconnects. Errors returned
+// before the first message are rendered by the response encoder, later ones
+// are written as a final error event.
+func ServeServerStream[Res any](s *Server, w http.ResponseWriter, r *http.Request, handler func(grpc.ServerStreamingServer[Res]) error) {
+	streamCtx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	ss := &serverStream{
+		ctx:    streamCtx,
+		w:      w,
+		codec:  encoding.GetCodec(json.Name),
+		ndjson: acceptsNDJSON(r),
+	}
+
+	heartbeat := s.streamHeartbeat
+	if heartbeat == 0 {
+		heartbeat = defaultStreamHeartbeat
+	}
+
+	if !ss.ndjson && heartbeat > 0 {
+		go ss.keepAlive(heartbeat)
+	}
+
+	err := handler(&grpc.GenericServerStream[any, Res]{ServerStream: ss})
+	cancel()
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if err == nil {
+		ss.writeHeaderLocked()
+		return
+	}
+
+	if !ss.wroteHeader {
+		s.WriteResponse(w, r, nil, err)
+		return
+	}
+
+	if r.Context().Err() != nil {
+		// client is gone
+		return
+	}
+
+	_ = ss.writeEventLocked("error", s.WrapHTTPResponse(nil, err))
+}
+
+func acceptsNDJSON(r *http.Request) bool {
+	for _, ar := range parseAccept(r.Header.Get("Accept")) {
+		switch ar.mediaType {
+		case EventStreamContentType:
+			return false
+		case NDJSONContentType:
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ss *serverStream) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ss.ctx.Done():
+			return
+		case <-ticker.C:
+			ss.mu.Lock()
+			if ss.ctx.Err() == nil {
+				ss.writeHeaderLocked()
+				_, err := io.WriteString(ss.w, ": heartbeat\n\n")
+				if err == nil {
+					ss.flushLocked()
+				}
+			}
+			ss.mu.Unlock()
+		}
+	}
+}
+
+func (ss *serverStream) SetHeader(md metadata.MD) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.wroteHeader {
+		return fmt.Errorf("http stream: headers already sent")
+	}
+
+	ss.header = metadata.Join(ss.header, md)
+	return nil
+}
+
+func (ss *serverStream) SendHeader(md metadata.MD) error {
+	if err := ss.SetHeader(md); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.writeHeaderLocked()
+	ss.flushLocked()
+	return nil
+}
+
+// SetTrailer is a no-op, trailers are not sent on event streams.
+func (ss *serverStream) SetTrailer(metadata.MD) {}
+
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
+}
+
+func (ss *serverStream) SendMsg(m any) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if err := ss.ctx.Err(); err != nil {
+		return err
+	}
+
+	return ss.writeEventLocked("", m)
+}
+
+func (ss *serverStream) RecvMsg(any) error {
+	return errStreamRecvNotSupported
+}
+
+func (ss *serverStream) writeHeaderLocked() {
+	if ss.wroteHeader {
+		return
+	}
+
+	ss.wroteHeader = true
+	header := ss.w.Header()
+	for k, vs := range ss.header {
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+
+	if ss.ndjson {
+		header.Set("Content-Type", NDJSONContentType)
+	} else {
+		header.Set("Content-Type", EventStreamContentType)
+		header.Set("Connection", "keep-alive")
+	}
+
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	ss.w.WriteHeader(http.StatusOK)
+}
+
+func (ss *serverStream) writeEventLocked(event string, m any) error {
+	data, err := ss.codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	ss.writeHeaderLocked()
+
+	buf := &bytes.Buffer{}
+	if ss.ndjson {
+		if event == "error" {
+			buf.WriteString(`{"error":`)
+			buf.Write(data)
+			buf.WriteString("}\n")
+		} else {
+			buf.Write(data)
+			buf.WriteByte('\n')
+		}
+	} else {
+		ss.seq++
+		buf.WriteString("id: " + strconv.Itoa(ss.seq) + "\n")
+		if event != "" {
+			buf.WriteString("event: " + event + "\n")
+		}
+
+		for line := range bytes.SplitSeq(data, []byte("\n")) {
+			buf.WriteString("data: ")
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	if _, err := ss.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	ss.flushLocked()
+	return nil
+}
+
+func (ss *serverStream) flushLocked() {
+	if f, ok := ss.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
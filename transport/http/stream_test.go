@@ -0,0 +1,143 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/internal/mock/stream"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type streamServer struct {
+	stream.UnimplementedStreamServiceServer
+
+	canceled chan struct{}
+	logIDs   chan string
+	recvErrs chan error
+}
+
+func (s *streamServer) Watch(req *stream.WatchRequest, ss grpc.ServerStreamingServer[stream.WatchEvent]) error {
+	switch req.GetTopic() {
+	case "missing":
+		return status.Error(codes.NotFound, "topic not found")
+	case "forever":
+		<-ss.Context().Done()
+		close(s.canceled)
+		return ss.Context().Err()
+	}
+
+	for i := int32(1); i <= req.GetCount(); i++ {
+		if err := ss.Send(&stream.WatchEvent{Topic: req.GetTopic(), Seq: i}); err != nil {
+			return err
+		}
+	}
+
+	if req.GetTopic() == "broken" {
+		return status.Error(codes.Internal, "broken topic")
+	}
+
+	return nil
+}
+
+func startStreamServer(t *testing.T, svc *streamServer) string {
+	srv := http.NewServer(http.StreamHeartbeat(50 * time.Millisecond))
+	stream.RegisterStreamServiceHTTPServer(srv, svc)
+	go func() {
+		if err := srv.Start(context.Background()); err != nil {
+			panic(err)
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	t.Cleanup(func() {
+		_ = srv.Stop(context.Background())
+	})
+
+	e, err := srv.Endpoint()
+	assert.NoError(t, err)
+	return e.String()
+}
+
+func getStream(t *testing.T, url, accept string) (*nhttp.Response, string) {
+	req, err := nhttp.NewRequest(nhttp.MethodGet, url, nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept", accept)
+
+	res, err := nhttp.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	return res, string(body)
+}
+
+func TestServerStreamSSE(t *testing.T) {
+	base := startStreamServer(t, &streamServer{})
+
+	res, body := getStream(t, base+"/watch/news?count=2", "text/event-stream")
+	assert.Equal(t, nhttp.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	events := strings.Split(strings.TrimSuffix(body, "\n\n"), "\n\n")
+	assert.Len(t, events, 2)
+	for i, event := range events {
+		lines := strings.Split(event, "\n")
+		assert.Len(t, lines, 2)
+		assert.Equal(t, fmt.Sprintf("id: %d", i+1), lines[0])
+		assert.JSONEq(t, fmt.Sprintf(`{"topic":"news","seq":%d}`, i+1), strings.TrimPrefix(lines[1], "data: "))
+	}
+
+	res, body = getStream(t, base+"/watch/news?count=2", "application/x-ndjson")
+	assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	assert.Len(t, lines, 2)
+	for i, line := range lines {
+		assert.JSONEq(t, fmt.Sprintf(`{"topic":"news","seq":%d}`, i+1), line)
+	}
+
+	// errors before the first event use the response encoder
+	res, body = getStream(t, base+"/watch/missing", "text/event-stream")
+	assert.Equal(t, nhttp.StatusNotFound, res.StatusCode)
+	assert.Contains(t, body, "topic not found")
+
+	// errors after the first event are sent as an error event
+	res, body = getStream(t, base+"/watch/broken?count=1", "text/event-stream")
+	assert.Equal(t, nhttp.StatusOK, res.StatusCode)
+	assert.Contains(t, body, "id: 2\nevent: error\ndata: ")
+	assert.Contains(t, body, "broken topic")
+}
+
+func TestServerStreamHeartbeatAndCancel(t *testing.T) {
+	svc := &streamServer{canceled: make(chan struct{})}
+	base := startStreamServer(t, svc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := nhttp.NewRequestWithContext(ctx, nhttp.MethodGet, base+"/watch/forever", nil)
+	assert.NoError(t, err)
+
+	res, err := nhttp.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(line, ": heartbeat"), line)
+
+	cancel()
+	select {
+	case <-svc.canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream context was not canceled after the client disconnected")
+	}
+}
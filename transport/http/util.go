@@ -4,16 +4,34 @@
 	"net/http"
 
 	"github.com/dizzrt/ellie/errors"
+	"github.com/dizzrt/ellie/pkg/ptrconv"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// ErrUnsupportedMediaType is returned when a request body has a content type
+// without a registered codec, it is rendered with status 415.
+var ErrUnsupportedMediaType = errors.NewStandardError(ptrconv.Ptr(codes.InvalidArgument), http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", "unsupported media type")
+
+// ErrRequestEntityTooLarge is returned when a request body exceeds
+// MaxRequestBodySize, it is rendered with status 413.
+var ErrRequestEntityTooLarge = errors.NewStandardError(ptrconv.Ptr(codes.ResourceExhausted), http.StatusRequestEntityTooLarge, "REQUEST_ENTITY_TOO_LARGE", "request entity too large")
+
 func HTTPStatusCodeFromError(err error) int {
 	if err == nil {
 		return http.StatusOK
 	}
 
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		return http.StatusUnsupportedMediaType
+	}
+
+	if errors.Is(err, ErrRequestEntityTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+
 	if se, ok := err.(*errors.StandardError); ok {
 		status := codes.Unknown
 		if se.Status() != nil {
@@ -53,3 +71,14 @@ func WrapHTTPResponse(code int, message string, data any, err error) gin.H {
 		"message": message,
 	}
 }
+
+// errorInfo returns the reason and metadata of the ErrorInfo detail of st.
+func errorInfo(st *status.Status) (string, map[string]string) {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason(), info.GetMetadata()
+		}
+	}
+
+	return "", nil
+}
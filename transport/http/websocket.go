@@ -0,0 +1,356 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dizzrt/ellie/encoding"
+	eproto "github.com/dizzrt/ellie/encoding/proto"
+	"github.com/dizzrt/ellie/errors"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// close codes in [4000, 4017) carry the grpc status that ended the stream
+	webSocketStatusCloseBase = 4000
+
+	maxCloseReasonLength = 123
+	webSocketCloseWait   = time.Second
+)
+
+// WebSocketEndOfStream is the payload of the frame a client sends to end its
+// side of a websocket stream. A single NUL byte is neither a valid protobuf
+// message nor valid json, xml or yaml text, so it can't be confused with a
+// message, not even an empty one.
+const WebSocketEndOfStream = "\x00"
+
+var _ grpc.ServerStream = (*webSocketStream)(nil)
+
+// webSocketStream adapts a websocket connection to grpc.ServerStream. Every
+// data frame carries one message encoded by the negotiated codec, a
+// WebSocketEndOfStream frame from the client ends the client side of the
+// stream.
+type webSocketStream struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	conn    *websocket.Conn
+	codec   encoding.Codec
+	msgType int
+
+	frames  chan []byte
+	done    chan struct{}
+	recvErr error
+
+	mu sync.Mutex
+}
+
+// ServeWebSocket upgrades the request and runs a client or bidi streaming
+// handler over the connection. The codec is chosen from the client's
+// subprotocols by codec name, e.g. json or proto, and falls back to the Accept
+// header. The stream context is derived from the upgrade request, it is
+// canceled when the client closes the connection. The returned error is sent
+// as the close code, see WebSocketCloseCode.
+func ServeWebSocket[Req, Res any](s *Server, w http.ResponseWriter, r *http.Request, handler func(*grpc.GenericServerStream[Req, Res]) error) {
+	codec, subprotocol := negotiateWebSocketCodec(r)
+
+	upgrader := &websocket.Upgrader{
+		CheckOrigin: s.webSocketCheckOrigin,
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			code := GRPCCodeFromHTTPStatus(status)
+			s.WriteResponse(w, r, nil, errors.NewStandardError(&code, int(code), "WEBSOCKET_UPGRADE_FAILED", reason.Error()))
+		},
+	}
+
+	var header http.Header
+	if subprotocol != "" {
+		header = http.Header{"Sec-Websocket-Protocol": {subprotocol}}
+	}
+
+	conn, err := upgrader.Upgrade(w, r, header)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	streamCtx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	ws := &webSocketStream{
+		ctx:     streamCtx,
+		cancel:  cancel,
+		conn:    conn,
+		codec:   codec,
+		msgType: websocket.TextMessage,
+		frames:  make(chan []byte),
+		done:    make(chan struct{}),
+	}
+
+	if codec.Name() == eproto.Name {
+		ws.msgType = websocket.BinaryMessage
+	}
+
+	go ws.readLoop()
+
+	heartbeat := s.streamHeartbeat
+	if heartbeat == 0 {
+		heartbeat = defaultStreamHeartbeat
+	}
+
+	if heartbeat > 0 {
+		go ws.keepAlive(heartbeat)
+	}
+
+	err = handler(&grpc.GenericServerStream[Req, Res]{ServerStream: ws})
+	ws.close(err)
+}
+
+// WebSocketCloseCode returns the close code sent when a websocket stream
+// ends with err, 1000 for nil and 4000 plus the grpc status otherwise.
+func WebSocketCloseCode(err error) int {
+	code := errors.StatusCodeFromError(err)
+	if code == codes.OK {
+		return websocket.CloseNormalClosure
+	}
+
+	return webSocketStatusCloseBase + int(code)
+}
+
+// ErrorFromWebSocketClose converts a close frame into an error, it is the
+// inverse of WebSocketCloseCode. Normal closures before the end of the stream
+// are reported as Canceled.
+func ErrorFromWebSocketClose(code int, text string) error {
+	var sc codes.Code
+	switch {
+	case code > webSocketStatusCloseBase && code < webSocketStatusCloseBase+errors.GRPC_STATUS_MAX_CODE:
+		sc = codes.Code(code - webSocketStatusCloseBase)
+	case code == websocket.CloseNormalClosure, code == websocket.CloseGoingAway, code == websocket.CloseNoStatusReceived:
+		sc = codes.Canceled
+	case code == websocket.CloseUnsupportedData, code == websocket.CloseInvalidFramePayloadData:
+		sc = codes.InvalidArgument
+	case code == websocket.ClosePolicyViolation:
+		sc = codes.PermissionDenied
+	case code == websocket.CloseMessageTooBig:
+		sc = codes.ResourceExhausted
+	case code == websocket.CloseInternalServerErr:
+		sc = codes.Internal
+	case code == websocket.CloseServiceRestart, code == websocket.CloseTryAgainLater:
+		sc = codes.Unavailable
+	default:
+		sc = codes.Unknown
+	}
+
+	if text == "" {
+		text = fmt.Sprintf("websocket closed with code %d", code)
+	}
+
+	return errors.NewStandardError(&sc, int(sc), "WEBSOCKET_CLOSED", text)
+}
+
+// UpgradeCarrier returns a carrier of the upgrade request for log and trace
+// propagation. Browsers can't set headers on websocket requests, so keys that
+// are missing in the headers are looked up in the query, both as is and in
+// snake case, e.g. log_id for log.id.
+func UpgradeCarrier(r *http.Request) propagation.TextMapCarrier {
+	return &upgradeCarrier{
+		HeaderCarrier: propagation.HeaderCarrier(r.Header),
+		query:         r.URL.Query(),
+	}
+}
+
+type upgradeCarrier struct {
+	propagation.HeaderCarrier
+
+	query map[string][]string
+}
+
+func (c *upgradeCarrier) Get(key string) string {
+	if v := c.HeaderCarrier.Get(key); v != "" {
+		return v
+	}
+
+	if v := c.query[key]; len(v) > 0 {
+		return v[0]
+	}
+
+	if v := c.query[strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToLower(key))]; len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+func negotiateWebSocketCodec(r *http.Request) (encoding.Codec, string) {
+	for _, protocol := range websocket.Subprotocols(r) {
+		if codec := encoding.GetCodec(protocol); codec != nil {
+			return codec, protocol
+		}
+	}
+
+	codec, _ := NegotiateCodec(r)
+	return codec, ""
+}
+
+func (ws *webSocketStream) readLoop() {
+	defer close(ws.done)
+
+	halfClosed := false
+	for {
+		_, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			if !halfClosed {
+				ws.recvErr = webSocketReadError(err)
+				close(ws.frames)
+			}
+
+			ws.cancel()
+			return
+		}
+
+		if halfClosed {
+			continue
+		}
+
+		if string(data) == WebSocketEndOfStream {
+			halfClosed = true
+			ws.recvErr = io.EOF
+			close(ws.frames)
+			continue
+		}
+
+		select {
+		case ws.frames <- data:
+		case <-ws.ctx.Done():
+		}
+	}
+}
+
+func webSocketReadError(err error) error {
+	var ce *websocket.CloseError
+	if errors.As(err, &ce) {
+		return ErrorFromWebSocketClose(ce.Code, ce.Text)
+	}
+
+	sc := codes.Canceled
+	return errors.NewStandardError(&sc, int(sc), "WEBSOCKET_CLOSED", err.Error())
+}
+
+func (ws *webSocketStream) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ws.ctx.Done():
+			return
+		case <-ticker.C:
+			_ = ws.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval))
+		}
+	}
+}
+
+// close sends the close frame for err and waits for the client to
+// acknowledge it.
+func (ws *webSocketStream) close(err error) {
+	ws.cancel()
+
+	reason := ""
+	if err != nil {
+		reason = closeReason(errorMessage(err))
+	}
+
+	ws.mu.Lock()
+	msg := websocket.FormatCloseMessage(WebSocketCloseCode(err), reason)
+	werr := ws.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(webSocketCloseWait))
+	ws.mu.Unlock()
+
+	if werr != nil {
+		return
+	}
+
+	select {
+	case <-ws.done:
+	case <-time.After(webSocketCloseWait):
+	}
+}
+
+func errorMessage(err error) string {
+	if se, ok := err.(*errors.StandardError); ok {
+		return se.Message()
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return st.Message()
+	}
+
+	return err.Error()
+}
+
+func closeReason(message string) string {
+	if len(message) <= maxCloseReasonLength {
+		return message
+	}
+
+	message = message[:maxCloseReasonLength]
+	for !utf8.ValidString(message) {
+		message = message[:len(message)-1]
+	}
+
+	return message
+}
+
+// SetHeader is a no-op, headers are sent with the upgrade response.
+func (ws *webSocketStream) SetHeader(metadata.MD) error {
+	return nil
+}
+
+// SendHeader is a no-op, headers are sent with the upgrade response.
+func (ws *webSocketStream) SendHeader(metadata.MD) error {
+	return nil
+}
+
+// SetTrailer is a no-op, trailers are not sent on websocket streams.
+func (ws *webSocketStream) SetTrailer(metadata.MD) {}
+
+func (ws *webSocketStream) Context() context.Context {
+	return ws.ctx
+}
+
+func (ws *webSocketStream) SendMsg(m any) error {
+	if err := ws.ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := ws.codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	return ws.conn.WriteMessage(ws.msgType, data)
+}
+
+func (ws *webSocketStream) RecvMsg(m any) error {
+	data, ok := <-ws.frames
+	if !ok {
+		return ws.recvErr
+	}
+
+	if err := ws.codec.Unmarshal(data, m); err != nil {
+		return InvalidArgumentError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package http_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dizzrt/ellie/errors"
+	"github.com/dizzrt/ellie/internal/mock/stream"
+	"github.com/dizzrt/ellie/log"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func (s *streamServer) Publish(ss grpc.ClientStreamingServer[stream.WatchEvent, stream.PublishSummary]) error {
+	s.logIDs <- log.LogIDFromContext(ss.Context())
+
+	var count int32
+	for {
+		_, err := ss.Recv()
+		if err == io.EOF {
+			return ss.SendAndClose(&stream.PublishSummary{Count: count})
+		}
+
+		if err != nil {
+			s.recvErrs <- err
+			return err
+		}
+
+		count++
+	}
+}
+
+func (s *streamServer) Echo(ss grpc.BidiStreamingServer[stream.WatchEvent, stream.WatchEvent]) error {
+	for {
+		event, err := ss.Recv()
+		if err != nil {
+			return err
+		}
+
+		if event.GetTopic() == "denied" {
+			return status.Error(codes.PermissionDenied, "topic denied")
+		}
+
+		if err := ss.Send(event); err != nil {
+			return err
+		}
+	}
+}
+
+func dialWebSocket(t *testing.T, url, protocol string) *websocket.Conn {
+	dialer := &websocket.Dialer{Subprotocols: []string{protocol}}
+	conn, res, err := dialer.Dial("ws"+strings.TrimPrefix(url, "http"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, protocol, res.Header.Get("Sec-Websocket-Protocol"))
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
+
+func TestWebSocketClientStream(t *testing.T) {
+	svc := &streamServer{logIDs: make(chan string, 2), recvErrs: make(chan error, 1)}
+	base := startStreamServer(t, svc)
+
+	conn := dialWebSocket(t, base+"/publish?log_id=ws-log-id", "json")
+	assert.Equal(t, "ws-log-id", <-svc.logIDs)
+	for range 3 {
+		assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"topic":"news"}`)))
+	}
+
+	// a reserved frame ends the client side of the stream
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(http.WebSocketEndOfStream)))
+
+	mt, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, websocket.TextMessage, mt)
+	assert.JSONEq(t, `{"count":3}`, string(data))
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+
+	// close codes from the client are reported by Recv
+	conn = dialWebSocket(t, base+"/publish", "json")
+	<-svc.logIDs
+	msg := websocket.FormatCloseMessage(http.WebSocketCloseCode(status.Error(codes.NotFound, "")), "gone")
+	assert.NoError(t, conn.WriteMessage(websocket.CloseMessage, msg))
+
+	recvErr := <-svc.recvErrs
+	assert.Equal(t, codes.NotFound, errors.StatusCodeFromError(recvErr))
+	assert.Contains(t, recvErr.Error(), "gone")
+}
+
+func TestWebSocketClientStreamEmptyMessage(t *testing.T) {
+	svc := &streamServer{logIDs: make(chan string, 1), recvErrs: make(chan error, 1)}
+	base := startStreamServer(t, svc)
+
+	conn := dialWebSocket(t, base+"/publish", "proto")
+	<-svc.logIDs
+
+	// a default message marshals to zero bytes and must still be received
+	data, err := proto.Marshal(&stream.WatchEvent{})
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+	assert.NoError(t, conn.WriteMessage(websocket.BinaryMessage, data))
+	assert.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte(http.WebSocketEndOfStream)))
+
+	mt, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, mt)
+
+	summary := &stream.PublishSummary{}
+	assert.NoError(t, proto.Unmarshal(data, summary))
+	assert.Equal(t, int32(1), summary.GetCount())
+}
+
+func TestWebSocketBidiStream(t *testing.T) {
+	base := startStreamServer(t, &streamServer{})
+	conn := dialWebSocket(t, base+"/echo", "proto")
+
+	for i := int32(1); i <= 2; i++ {
+		data, err := proto.Marshal(&stream.WatchEvent{Topic: "news", Seq: i})
+		assert.NoError(t, err)
+		assert.NoError(t, conn.WriteMessage(websocket.BinaryMessage, data))
+
+		mt, data, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, websocket.BinaryMessage, mt)
+
+		event := &stream.WatchEvent{}
+		assert.NoError(t, proto.Unmarshal(data, event))
+		assert.Equal(t, i, event.GetSeq())
+	}
+
+	// handler errors are sent as the close code
+	data, err := proto.Marshal(&stream.WatchEvent{Topic: "denied"})
+	assert.NoError(t, err)
+	assert.NoError(t, conn.WriteMessage(websocket.BinaryMessage, data))
+
+	_, _, err = conn.ReadMessage()
+	ce, ok := err.(*websocket.CloseError)
+	assert.True(t, ok, err)
+	assert.Equal(t, 4000+int(codes.PermissionDenied), ce.Code)
+	assert.Equal(t, "topic denied", ce.Text)
+	assert.Equal(t, codes.PermissionDenied, errors.StatusCodeFromError(http.ErrorFromWebSocketClose(ce.Code, ce.Text)))
+}
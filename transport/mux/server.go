@@ -0,0 +1,110 @@
+package mux
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/log"
+	"github.com/dizzrt/ellie/transport"
+	"github.com/dizzrt/ellie/transport/grpc"
+	"github.com/dizzrt/ellie/transport/http"
+)
+
+var (
+	_ transport.Server          = (*Server)(nil)
+	_ transport.Endpointer      = (*Server)(nil)
+	_ transport.MultiEndpointer = (*Server)(nil)
+)
+
+const grpcContentType = "application/grpc"
+
+// Server serves a grpc server and an http server on the listener of the http
+// server. HTTP/2 requests with an application/grpc content type are passed to
+// the grpc server, everything else to the http server. Cleartext HTTP/2 is
+// enabled on the listener for grpc clients.
+type Server struct {
+	grpc *grpc.Server
+	http *http.Server
+}
+
+// NewServer combines gs and hs, gs is served by hs and must not be started on
+// its own. Listener, TLS and timeout options are taken from hs.
+func NewServer(gs *grpc.Server, hs *http.Server) *Server {
+	next := hs.Handler
+	hs.Handler = nhttp.HandlerFunc(func(w nhttp.ResponseWriter, r *nhttp.Request) {
+		if isGRPCRequest(r) {
+			gs.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	hs.Protocols = new(nhttp.Protocols)
+	hs.Protocols.SetHTTP1(true)
+	hs.Protocols.SetHTTP2(true)
+	hs.Protocols.SetUnencryptedHTTP2(true)
+
+	return &Server{
+		grpc: gs,
+		http: hs,
+	}
+}
+
+func isGRPCRequest(r *nhttp.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), grpcContentType)
+}
+
+// region interfaces impl
+
+func (s *Server) Start(ctx context.Context) error {
+	s.grpc.Serving(ctx)
+	log.Info("[MUX] serving gRPC and HTTP on a single port")
+
+	return s.http.Start(ctx)
+}
+
+// Stop shuts the http server down gracefully, in-flight grpc calls are
+// waited for like http requests. Calls still running when ctx is done are
+// canceled.
+func (s *Server) Stop(ctx context.Context) error {
+	s.grpc.NotServing()
+	err := s.http.Stop(ctx)
+	s.grpc.Server.Stop()
+
+	return err
+}
+
+// Endpoint returns the endpoint of the http server.
+func (s *Server) Endpoint() (*url.URL, error) {
+	return s.http.Endpoint()
+}
+
+// Endpoints returns the http endpoint and a grpc endpoint with the same
+// host, e.g. http://10.0.0.1:8000 and grpc://10.0.0.1:8000.
+func (s *Server) Endpoints() ([]*url.URL, error) {
+	e, err := s.http.Endpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	var scheme string
+	switch e.Scheme {
+	case "http":
+		scheme = "grpc"
+	case "https":
+		scheme = "grpcs"
+	default:
+		// unix sockets use the same endpoint for both
+		return []*url.URL{e}, nil
+	}
+
+	ge := *e
+	ge.Scheme = scheme
+	return []*url.URL{e, &ge}, nil
+}
+
+// endregion
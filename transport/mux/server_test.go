@@ -0,0 +1,83 @@
+package mux_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	nhttp "net/http"
+
+	"github.com/dizzrt/ellie/internal/mock/ping"
+	"github.com/dizzrt/ellie/transport/grpc"
+	"github.com/dizzrt/ellie/transport/http"
+	"github.com/dizzrt/ellie/transport/mux"
+	"github.com/stretchr/testify/assert"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type pingServer struct {
+	ping.UnimplementedPingServiceServer
+}
+
+func (s *pingServer) Ping(ctx context.Context, req *ping.PingRequest) (*ping.PingResponse, error) {
+	return &ping.PingResponse{Message: "pong"}, nil
+}
+
+func TestServer(t *testing.T) {
+	gs := grpc.NewServer()
+	ping.RegisterPingServiceServer(gs, &pingServer{})
+
+	hs := http.NewServer(http.Address("127.0.0.1:0"))
+	ping.RegisterPingServiceHTTPServer(hs, &pingServer{})
+
+	srv := mux.NewServer(gs, hs)
+	go func() {
+		if err := srv.Start(context.Background()); err != nil {
+			panic(err)
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	endpoints, err := srv.Endpoints()
+	assert.NoError(t, err)
+	if !assert.Len(t, endpoints, 2) {
+		return
+	}
+
+	assert.Equal(t, "http", endpoints[0].Scheme)
+	assert.Equal(t, "grpc", endpoints[1].Scheme)
+	assert.Equal(t, endpoints[0].Host, endpoints[1].Host)
+
+	// http
+	res, err := nhttp.Get(endpoints[0].String() + "/ping")
+	assert.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, nhttp.StatusOK, res.StatusCode)
+	assert.Contains(t, string(body), "pong")
+
+	// grpc
+	conn, err := ggrpc.NewClient(endpoints[1].Host, ggrpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	reply, err := ping.NewPingServiceClient(conn).Ping(context.Background(), &ping.PingRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", reply.GetMessage())
+
+	hc, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, hc.GetStatus())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, srv.Stop(ctx))
+
+	_, err = nhttp.Get(endpoints[0].String() + "/ping")
+	assert.Error(t, err)
+}
@@ -13,3 +13,9 @@ type Server interface {
 type Endpointer interface {
 	Endpoint() (*url.URL, error)
 }
+
+// MultiEndpointer is implemented by servers reachable by more than one
+// scheme, e.g. grpc and http on a single port.
+type MultiEndpointer interface {
+	Endpoints() ([]*url.URL, error)
+}